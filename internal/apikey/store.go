@@ -0,0 +1,356 @@
+package apikey
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Xushengqwer/go-common/models/enums"
+)
+
+// keyPrefix 是明文 Key 的固定前缀，便于在日志与代码仓库扫描中识别泄露的 Key
+const keyPrefix = "gk"
+
+// reloadCheckInterval 是检查存储文件是否被其他进程（如 CLI）修改的最小间隔
+const reloadCheckInterval = 5 * time.Second
+
+var (
+	// ErrNotFound 表示 Key 不存在
+	ErrNotFound = errors.New("API Key 不存在")
+	// ErrInvalidKey 表示 Key 格式错误、不存在、已吊销或已过期
+	ErrInvalidKey = errors.New("API Key 无效")
+)
+
+// Key 是存储中的一条 API Key 记录，明文只在创建和轮换时返回一次
+type Key struct {
+	ID        string         `json:"id"`
+	Owner     string         `json:"owner"`              // Key 持有者（合作方标识），认证后作为 X-User-ID 透传
+	Role      enums.UserRole `json:"role"`               // 参与 allowedRoles 判断的角色
+	Scopes    []string       `json:"scopes,omitempty"`   // 授权范围，透传给上游
+	Services  []string       `json:"services,omitempty"` // 允许访问的服务名，为空表示不限制
+	Plan      string         `json:"plan,omitempty"`     // 限流套餐名，对应 apiKeys.plans，为空表示不限流
+	Hash      string         `json:"hash,omitempty"`     // 密钥部分的 SHA-256（hex）
+	CreatedAt time.Time      `json:"createdAt"`
+	RotatedAt *time.Time     `json:"rotatedAt,omitempty"`
+	RevokedAt *time.Time     `json:"revokedAt,omitempty"`
+	ExpiresAt *time.Time     `json:"expiresAt,omitempty"`
+
+	// 轮换后旧密钥的宽限期，便于调用方平滑切换
+	PreviousHash      string     `json:"previousHash,omitempty"`
+	PreviousExpiresAt *time.Time `json:"previousExpiresAt,omitempty"`
+}
+
+// Active 判断 Key 在 now 时刻是否可用
+func (k *Key) Active(now time.Time) bool {
+	return k.RevokedAt == nil && (k.ExpiresAt == nil || now.Before(*k.ExpiresAt))
+}
+
+// AllowsService 判断 Key 是否允许访问指定服务
+func (k *Key) AllowsService(name string) bool {
+	if len(k.Services) == 0 {
+		return true
+	}
+	for _, s := range k.Services {
+		if s == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Public 返回去掉哈希字段的副本，供管理 API 与 CLI 输出
+func (k Key) Public() Key {
+	k.Hash = ""
+	k.PreviousHash = ""
+	return k
+}
+
+// CreateOptions 是创建 Key 时的属性
+type CreateOptions struct {
+	Owner     string
+	Role      enums.UserRole
+	Scopes    []string
+	Services  []string
+	Plan      string
+	ExpiresAt *time.Time
+}
+
+// Store 是基于 JSON 文件的 API Key 存储
+// - 每次修改都原子地写回文件（临时文件 + rename）
+// - Verify 最多每 reloadCheckInterval 检查一次文件修改时间，其他进程写入后自动重新加载
+type Store struct {
+	path string
+
+	mu        sync.Mutex
+	keys      map[string]*Key
+	modTime   time.Time
+	checkedAt time.Time
+}
+
+// Open 打开存储文件，文件不存在时视为空存储（首次写入时创建）
+func Open(path string) (*Store, error) {
+	s := &Store{path: path, keys: make(map[string]*Key)}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.loadLocked(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Create 创建 Key，返回记录与明文（明文不会被保存）
+func (s *Store) Create(opts CreateOptions) (Key, string, error) {
+	if opts.Owner == "" {
+		return Key{}, "", errors.New("owner 不能为空")
+	}
+	id, err := randomHex(6)
+	if err != nil {
+		return Key{}, "", err
+	}
+	secret, hash, err := newSecret()
+	if err != nil {
+		return Key{}, "", err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.syncLocked()
+	key := &Key{
+		ID:        id,
+		Owner:     opts.Owner,
+		Role:      opts.Role,
+		Scopes:    opts.Scopes,
+		Services:  opts.Services,
+		Plan:      opts.Plan,
+		Hash:      hash,
+		CreatedAt: time.Now().UTC(),
+		ExpiresAt: opts.ExpiresAt,
+	}
+	s.keys[id] = key
+	if err := s.saveLocked(); err != nil {
+		delete(s.keys, id)
+		return Key{}, "", err
+	}
+	return key.Public(), formatKey(id, secret), nil
+}
+
+// List 按创建时间返回所有 Key（不含哈希）
+func (s *Store) List() []Key {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.refreshLocked()
+	keys := make([]Key, 0, len(s.keys))
+	for _, k := range s.keys {
+		keys = append(keys, k.Public())
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].CreatedAt.Before(keys[j].CreatedAt) })
+	return keys
+}
+
+// Rotate 为 Key 生成新密钥并返回新明文，旧密钥在 grace 时长内仍然有效（grace 为 0 时立即失效）
+func (s *Store) Rotate(id string, grace time.Duration) (Key, string, error) {
+	secret, hash, err := newSecret()
+	if err != nil {
+		return Key{}, "", err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.syncLocked()
+	key, ok := s.keys[id]
+	if !ok {
+		return Key{}, "", ErrNotFound
+	}
+	if key.RevokedAt != nil {
+		return Key{}, "", errors.New("已吊销的 API Key 不能轮换")
+	}
+	old := *key
+	now := time.Now().UTC()
+	key.PreviousHash, key.PreviousExpiresAt = "", nil
+	if grace > 0 {
+		until := now.Add(grace)
+		key.PreviousHash, key.PreviousExpiresAt = key.Hash, &until
+	}
+	key.Hash = hash
+	key.RotatedAt = &now
+	if err := s.saveLocked(); err != nil {
+		*key = old
+		return Key{}, "", err
+	}
+	return key.Public(), formatKey(id, secret), nil
+}
+
+// Revoke 吊销 Key，吊销后立即失效；记录保留以便审计
+func (s *Store) Revoke(id string) (Key, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.syncLocked()
+	key, ok := s.keys[id]
+	if !ok {
+		return Key{}, ErrNotFound
+	}
+	if key.RevokedAt == nil {
+		now := time.Now().UTC()
+		key.RevokedAt = &now
+		if err := s.saveLocked(); err != nil {
+			key.RevokedAt = nil
+			return Key{}, err
+		}
+	}
+	return key.Public(), nil
+}
+
+// Verify 校验明文 Key，返回对应记录的副本
+func (s *Store) Verify(plaintext string) (Key, error) {
+	id, secret, ok := parseKey(plaintext)
+	if !ok {
+		return Key{}, ErrInvalidKey
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.refreshLocked()
+	key, found := s.keys[id]
+	if !found {
+		return Key{}, ErrInvalidKey
+	}
+	now := time.Now()
+	if !key.Active(now) {
+		return Key{}, ErrInvalidKey
+	}
+	hash := hashSecret(secret)
+	if subtle.ConstantTimeCompare([]byte(hash), []byte(key.Hash)) == 1 {
+		return *key, nil
+	}
+	if key.PreviousHash != "" && key.PreviousExpiresAt != nil && now.Before(*key.PreviousExpiresAt) &&
+		subtle.ConstantTimeCompare([]byte(hash), []byte(key.PreviousHash)) == 1 {
+		return *key, nil
+	}
+	return Key{}, ErrInvalidKey
+}
+
+// refreshLocked 最多每 reloadCheckInterval 检查一次存储文件，调用方需持有锁
+func (s *Store) refreshLocked() {
+	if time.Since(s.checkedAt) < reloadCheckInterval {
+		return
+	}
+	s.syncLocked()
+}
+
+// syncLocked 在存储文件被其他进程修改后重新加载，调用方需持有锁
+// - 修改操作前总是调用，避免覆盖其他进程刚写入的数据
+// - 重新加载失败时继续使用内存中的数据
+func (s *Store) syncLocked() {
+	s.checkedAt = time.Now()
+	if info, err := os.Stat(s.path); err == nil && !info.ModTime().Equal(s.modTime) {
+		_ = s.loadLocked()
+	}
+}
+
+// loadLocked 从文件加载全部 Key，调用方需持有锁
+func (s *Store) loadLocked() error {
+	s.checkedAt = time.Now()
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("读取 API Key 存储失败: %w", err)
+	}
+	var list []*Key
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &list); err != nil {
+			return fmt.Errorf("解析 API Key 存储 %s 失败: %w", s.path, err)
+		}
+	}
+	keys := make(map[string]*Key, len(list))
+	for _, k := range list {
+		keys[k.ID] = k
+	}
+	s.keys = keys
+	if info, err := os.Stat(s.path); err == nil {
+		s.modTime = info.ModTime()
+	}
+	return nil
+}
+
+// saveLocked 把全部 Key 原子地写回文件，调用方需持有锁
+func (s *Store) saveLocked() error {
+	list := make([]*Key, 0, len(s.keys))
+	for _, k := range s.keys {
+		list = append(list, k)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].CreatedAt.Before(list[j].CreatedAt) })
+	data, err := json.MarshalIndent(list, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("写入 API Key 存储失败: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("写入 API Key 存储失败: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("写入 API Key 存储失败: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), s.path); err != nil {
+		return fmt.Errorf("写入 API Key 存储失败: %w", err)
+	}
+	if info, err := os.Stat(s.path); err == nil {
+		s.modTime = info.ModTime()
+	}
+	return nil
+}
+
+// newSecret 生成 32 字节随机密钥，返回明文（hex）与哈希
+func newSecret() (string, string, error) {
+	secret, err := randomHex(32)
+	if err != nil {
+		return "", "", err
+	}
+	return secret, hashSecret(secret), nil
+}
+
+// hashSecret 计算密钥的 SHA-256；密钥本身是高熵随机值，无需加盐或慢哈希
+func hashSecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+// formatKey 拼接明文 Key："gk_<id>_<secret>"
+func formatKey(id, secret string) string {
+	return keyPrefix + "_" + id + "_" + secret
+}
+
+// parseKey 解析明文 Key，返回 ID 与密钥部分
+func parseKey(plaintext string) (string, string, bool) {
+	parts := strings.Split(plaintext, "_")
+	if len(parts) != 3 || parts[0] != keyPrefix || parts[1] == "" || parts[2] == "" {
+		return "", "", false
+	}
+	return parts[1], parts[2], true
+}
+
+// randomHex 返回 n 字节随机数的 hex 编码
+func randomHex(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("生成随机数失败: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,100 @@
+package audit
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// rotatingFile 是按大小轮转的文件写入器
+// - 当前文件写满 maxSize 后依次重命名为 <path>.1、<path>.2 ...，超出 maxBackups 的最旧文件被删除
+type rotatingFile struct {
+	path       string
+	maxSize    int64
+	maxBackups int
+
+	mu   sync.Mutex
+	file *os.File
+	size int64
+}
+
+func openRotatingFile(path string, maxSize int64, maxBackups int) (*rotatingFile, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, fmt.Errorf("创建审计日志目录失败: %w", err)
+	}
+	r := &rotatingFile{path: path, maxSize: maxSize, maxBackups: maxBackups}
+	if err := r.open(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// open 以追加方式打开当前文件，并记录已有大小
+func (r *rotatingFile) open() error {
+	f, err := os.OpenFile(r.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o640)
+	if err != nil {
+		return fmt.Errorf("打开审计日志文件失败: %w", err)
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return fmt.Errorf("读取审计日志文件信息失败: %w", err)
+	}
+	r.file = f
+	r.size = info.Size()
+	return nil
+}
+
+// Write 实现 io.Writer；单条记录不会被拆分到两个文件
+func (r *rotatingFile) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.size > 0 && r.size+int64(len(p)) > r.maxSize {
+		if err := r.rotate(); err != nil {
+			return 0, err
+		}
+	}
+	n, err := r.file.Write(p)
+	r.size += int64(n)
+	return n, err
+}
+
+// Sync 实现 zapcore.WriteSyncer
+func (r *rotatingFile) Sync() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.file.Sync()
+}
+
+// Close 关闭当前文件
+func (r *rotatingFile) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.file.Close()
+}
+
+// rotate 依次后移历史文件并关闭当前文件，调用方需持有锁
+// - 后移历史文件失败时当前文件保持打开；重命名当前文件失败时重新打开它继续追加。两种情况都在下次写入时重试轮转
+func (r *rotatingFile) rotate() error {
+	if err := os.Remove(r.backupName(r.maxBackups)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("删除最旧的审计日志文件失败: %w", err)
+	}
+	for i := r.maxBackups - 1; i >= 1; i-- {
+		if err := os.Rename(r.backupName(i), r.backupName(i+1)); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("后移审计日志历史文件失败: %w", err)
+		}
+	}
+	if err := r.file.Close(); err != nil {
+		return fmt.Errorf("关闭审计日志文件失败: %w", err)
+	}
+	if err := os.Rename(r.path, r.backupName(1)); err != nil && !os.IsNotExist(err) {
+		return errors.Join(fmt.Errorf("轮转审计日志文件失败: %w", err), r.open())
+	}
+	return r.open()
+}
+
+func (r *rotatingFile) backupName(i int) string {
+	return fmt.Sprintf("%s.%d", r.path, i)
+}
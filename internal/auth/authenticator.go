@@ -0,0 +1,137 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/Xushengqwer/gateway/internal/config"
+	"github.com/Xushengqwer/go-common/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Authenticator 是一种认证方式
+// - 请求未携带该方式的凭证时返回 Missing 错误，Chain 会继续尝试下一种方式
+// - 携带了凭证但校验失败时返回 *Error（或其他错误，按 401 处理），Chain 立即拒绝请求
+type Authenticator interface {
+	Scheme() string
+	Authenticate(c *gin.Context) (*Principal, error)
+}
+
+// Error 是认证失败的错误，携带返回给客户端的状态码、业务码与消息
+type Error struct {
+	Status  int
+	Code    int
+	Message string
+	missing bool
+}
+
+func (e *Error) Error() string { return fmt.Sprintf("%d %s", e.Status, e.Message) }
+
+// Unauthorized 返回 401 认证失败错误
+func Unauthorized(msg string) *Error {
+	return &Error{Status: http.StatusUnauthorized, Code: response.ErrCodeClientUnauthorized, Message: msg}
+}
+
+// Forbidden 返回 403 错误，用于凭证有效但调用方被禁止的情况（如用户被拉黑）
+func Forbidden(msg string) *Error {
+	return &Error{Status: http.StatusForbidden, Code: response.ErrCodeClientForbidden, Message: msg}
+}
+
+// Missing 返回“未携带凭证”错误，msg 在所有方式都未携带凭证时作为 401 响应消息
+func Missing(msg string) *Error {
+	e := Unauthorized(msg)
+	e.missing = true
+	return e
+}
+
+// IsMissing 判断错误是否表示请求未携带该方式的凭证
+func IsMissing(err error) bool {
+	var e *Error
+	return errors.As(err, &e) && e.missing
+}
+
+// Chain 按配置顺序依次尝试多种认证方式
+type Chain struct {
+	authenticators map[string]Authenticator
+}
+
+// NewChain 用给定的认证方式创建认证链，nil 项会被忽略
+func NewChain(authenticators ...Authenticator) *Chain {
+	ch := &Chain{authenticators: make(map[string]Authenticator)}
+	for _, a := range authenticators {
+		if a != nil {
+			ch.authenticators[a.Scheme()] = a
+		}
+	}
+	return ch
+}
+
+// Authenticate 依次尝试 schemes 中的认证方式，第一个成功的方式产出的身份写入上下文
+// - 某种方式的凭证校验失败时立即拒绝，不再尝试后续方式
+// - 都未携带凭证时按第一种方式的提示返回 401
+// - 失败时已写入错误响应并中止请求，返回 false
+func (ch *Chain) Authenticate(c *gin.Context, schemes []string) bool {
+	var firstMissing error
+	for _, scheme := range schemes {
+		a, ok := ch.authenticators[scheme]
+		if !ok {
+			continue
+		}
+		p, err := a.Authenticate(c)
+		if err == nil {
+			p.Scheme = scheme
+			SetPrincipal(c, p)
+			return true
+		}
+		if IsMissing(err) {
+			if firstMissing == nil {
+				firstMissing = err
+			}
+			continue
+		}
+		respond(c, err)
+		return false
+	}
+	if firstMissing == nil {
+		firstMissing = Unauthorized("未配置可用的认证方式")
+	}
+	respond(c, firstMissing)
+	return false
+}
+
+// failureKey 是 gin.Context 中存放认证失败原因 (*Error) 的键
+const failureKey = "gatewayAuthFailure"
+
+// FailureFrom 返回认证链拒绝请求的原因，供日志与审计使用
+func FailureFrom(c *gin.Context) (*Error, bool) {
+	v, ok := c.Get(failureKey)
+	if !ok {
+		return nil, false
+	}
+	e, ok := v.(*Error)
+	return e, ok
+}
+
+// respond 写入认证失败响应并中止请求
+func respond(c *gin.Context, err error) {
+	var e *Error
+	if !errors.As(err, &e) {
+		e = Unauthorized(err.Error())
+	}
+	c.Set(failureKey, e)
+	response.RespondError(c, e.Status, e.Code, e.Message)
+	c.Abort()
+}
+
+// anonymous 是 "none" 认证方式：总是成功，产出访客身份
+type anonymous struct{}
+
+// NewAnonymous 返回 "none" 认证方式
+// - 放在 auth 列表末尾时表示凭证可选：未携带凭证的请求以访客角色继续参与 allowedRoles 判断
+func NewAnonymous() Authenticator { return anonymous{} }
+
+func (anonymous) Scheme() string { return config.AuthSchemeNone }
+
+func (anonymous) Authenticate(*gin.Context) (*Principal, error) { return Anonymous(), nil }
@@ -0,0 +1,85 @@
+package auth
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/Xushengqwer/gateway/internal/config"
+	"github.com/Xushengqwer/go-common/constants"
+	"github.com/Xushengqwer/go-common/models/enums"
+
+	"github.com/gin-gonic/gin"
+)
+
+// principalKey 是 gin.Context 中存放 *Principal 的键
+const principalKey = "gatewayPrincipal"
+
+// identityHeaders 是网关写给上游的身份请求头；客户端自带的同名头在代理前一律清除（包括无需认证的公开路径），防止伪造
+var identityHeaders = []string{
+	"X-User-ID", "X-User-Role", "X-User-Status", "X-User-Scopes", "X-Auth-Scheme", "X-API-Key-ID", "X-HMAC-Key-ID",
+}
+
+// Principal 是认证通过后的调用方身份，所有认证方式产出同一结构，供权限判断使用
+type Principal struct {
+	ID       string           // 用户 ID / 服务身份 / API Key 持有者
+	Role     enums.UserRole   // 参与 allowedRoles 判断的角色
+	Status   enums.UserStatus // 用户状态，非 JWT 身份恒为 StatusActive
+	Scheme   string           // 产出该身份的认证方式，如 "jwt"、"apikey"
+	Platform string           // 客户端平台，仅 JWT 身份携带
+	Scopes   []string         // 授权范围，透传给上游
+
+	// Headers 是认证方式特有、需要透传给上游的附加请求头，如 X-API-Key-ID
+	Headers map[string]string
+}
+
+// Anonymous 返回未认证调用方的身份（访客角色）
+func Anonymous() *Principal {
+	return &Principal{Role: enums.RoleGuest, Status: enums.StatusActive, Scheme: config.AuthSchemeNone}
+}
+
+// SetPrincipal 把身份写入上下文与转发给上游的请求头
+// - 同时写入 go-common 约定的 UserIDKey / RoleKey / StatusKey / PlatformKey，保持与现有中间件兼容
+func SetPrincipal(c *gin.Context, p *Principal) {
+	c.Set(principalKey, p)
+	c.Set(string(constants.StatusKey), p.Status)
+	c.Set(string(constants.RoleKey), p.Role)
+	c.Set(string(constants.UserIDKey), p.ID)
+	if p.Platform != "" {
+		c.Set(string(constants.PlatformKey), p.Platform)
+	}
+
+	header := c.Request.Header
+	StripIdentityHeaders(header)
+	header.Set("X-Auth-Scheme", p.Scheme)
+	if p.ID != "" {
+		header.Set("X-User-ID", p.ID)
+		header.Set("X-User-Role", p.Role.String())
+		header.Set("X-User-Status", p.Status.String())
+	}
+	if p.Platform != "" {
+		header.Set("X-Platform", p.Platform)
+	}
+	if len(p.Scopes) > 0 {
+		header.Set("X-User-Scopes", strings.Join(p.Scopes, " "))
+	}
+	for name, value := range p.Headers {
+		header.Set(name, value)
+	}
+}
+
+// PrincipalFrom 读取当前请求已认证的身份
+func PrincipalFrom(c *gin.Context) (*Principal, bool) {
+	v, ok := c.Get(principalKey)
+	if !ok {
+		return nil, false
+	}
+	p, ok := v.(*Principal)
+	return p, ok && p != nil
+}
+
+// StripIdentityHeaders 清除客户端自带的身份请求头，代理处理器对每个请求调用，SetPrincipal 写入前也会再次清除
+func StripIdentityHeaders(h http.Header) {
+	for _, name := range identityHeaders {
+		h.Del(name)
+	}
+}
@@ -0,0 +1,370 @@
+package cache
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/Xushengqwer/gateway/internal/config"
+	sharedCore "github.com/Xushengqwer/go-common/core"
+
+	"go.uber.org/zap"
+)
+
+const (
+	defaultMaxBytes      = 64 << 20 // 默认缓存总容量 64MiB
+	defaultMaxEntryBytes = 1 << 20  // 默认单条响应上限 1MiB
+)
+
+// FetchFunc 向上游发起请求并把响应写入 w
+// - req 的 Context 已与客户端连接解绑，客户端断开不会中断共享的上游请求
+// - w 在响应体超过 maxEntryBytes 前只记录响应，超过后直接转发给客户端
+type FetchFunc func(w http.ResponseWriter, req *http.Request)
+
+// Policy 是命中某条缓存规则后的缓存策略
+type Policy struct {
+	Service              string
+	TTL                  time.Duration
+	StaleWhileRevalidate time.Duration
+	KeyHeaders           []string
+}
+
+// PolicyFromRule 把服务的缓存规则转换为缓存策略
+func PolicyFromRule(service string, rule config.CacheRule) Policy {
+	return Policy{
+		Service:              service,
+		TTL:                  rule.TTL,
+		StaleWhileRevalidate: rule.StaleWhileRevalidate,
+		KeyHeaders:           rule.KeyHeaders,
+	}
+}
+
+// Stats 是缓存的运行统计，供管理 API 输出
+type Stats struct {
+	Entries int   `json:"entries"`
+	Bytes   int64 `json:"bytes"`
+	Hits    int64 `json:"hits"`
+	Stale   int64 `json:"stale"`
+	Misses  int64 `json:"misses"`
+}
+
+// Cache 是网关的 HTTP 响应缓存
+// - 内存 LRU，按字节数限制容量
+// - 尊重上游 Cache-Control / ETag / Vary，支持 stale-while-revalidate 和并发未命中合并
+type Cache struct {
+	store         *lruStore
+	flights       flightGroup
+	varies        sync.Map // 主键 -> 上游 Vary 声明的请求头列表（未声明时为空列表），没有记录表示尚未得知
+	maxEntryBytes int64
+	defaultTTL    time.Duration
+	logger        *sharedCore.ZapLogger
+
+	hits, stale, misses atomic.Int64
+}
+
+// New 根据配置创建响应缓存
+func New(cfg *config.ResponseCacheConfig, logger *sharedCore.ZapLogger) *Cache {
+	maxBytes := cfg.MaxBytes
+	if maxBytes <= 0 {
+		maxBytes = defaultMaxBytes
+	}
+	maxEntryBytes := cfg.MaxEntryBytes
+	if maxEntryBytes <= 0 {
+		maxEntryBytes = defaultMaxEntryBytes
+	}
+	return &Cache{
+		store:         newLRUStore(maxBytes),
+		maxEntryBytes: maxEntryBytes,
+		defaultTTL:    cfg.DefaultTTL,
+		logger:        logger,
+	}
+}
+
+// Cacheable 判断请求是否可以走缓存：仅 GET/HEAD 且不携带认证信息
+func Cacheable(req *http.Request) bool {
+	if req.Method != http.MethodGet && req.Method != http.MethodHead {
+		return false
+	}
+	if req.Header.Get("Authorization") != "" {
+		return false
+	}
+	return !strings.Contains(strings.ToLower(req.Header.Get("Cache-Control")), "no-store")
+}
+
+// Serve 以缓存优先的方式响应请求
+// - publicPath: 网关对外的请求路径，用于缓存键与按前缀清除
+// - fetch: 未命中或需要刷新时调用
+// - 响应体超过 maxEntryBytes 时不缓存，超出部分直接转发给客户端，内存占用不超过该上限
+func (c *Cache) Serve(w http.ResponseWriter, req *http.Request, publicPath string, policy Policy, fetch FetchFunc) {
+	primary := primaryKey(req, publicPath, policy)
+	key := primary
+	vary, varyKnown := c.varies.Load(primary)
+	if varyKnown {
+		key = primary + varySuffix(req.Header, vary.([]string))
+	}
+
+	now := time.Now()
+	if entry, ok := c.store.get(key); ok {
+		switch {
+		case now.Before(entry.Expires):
+			c.hits.Add(1)
+			writeEntry(w, req, entry, "HIT", now)
+			return
+		case now.Before(entry.StaleUntil):
+			c.stale.Add(1)
+			writeEntry(w, req, entry, "STALE", now)
+			c.revalidate(key, primary, publicPath, req, policy, entry, fetch)
+			return
+		}
+	}
+
+	c.misses.Add(1)
+	load := func() *Response {
+		return c.load(w, req, primary, publicPath, policy, fetch)
+	}
+	var (
+		resp   *Response
+		shared bool
+	)
+	if varyKnown {
+		resp, shared = c.flights.do(key, load)
+		if resp == nil && shared {
+			// 合并的请求响应体超过单条上限，已直接转发给发起方，本请求单独回源
+			resp, shared = load(), false
+		}
+	} else {
+		// 尚不知道上游按哪些请求头 Vary 时不合并，否则 Accept-Language 等取值不同的首批请求会拿到同一个响应
+		resp = load()
+	}
+	if resp == nil {
+		return // 响应体超过单条上限，已直接转发给客户端
+	}
+	status := "MISS"
+	if shared {
+		status = "COALESCED"
+	}
+	writeResponse(w, req, resp, status, "")
+}
+
+// load 回源并在响应可缓存时写入缓存
+// - 响应体不超过 maxEntryBytes 时返回完整响应，由调用方写给客户端
+// - 超过时响应已直接转发给 w，返回 nil
+func (c *Cache) load(w http.ResponseWriter, req *http.Request, primary, publicPath string, policy Policy, fetch FetchFunc) *Response {
+	upstreamReq := req.Clone(context.WithoutCancel(req.Context()))
+	if upstreamReq.Method == http.MethodHead {
+		upstreamReq.Method = http.MethodGet // 用 GET 填充缓存，HEAD 请求只回写头部
+	}
+	upstreamReq.Header.Del("If-None-Match")
+	upstreamReq.Header.Del("If-Modified-Since")
+	upstreamReq.Header.Del("Accept-Encoding") // 只缓存未压缩的原始响应，压缩由网关按客户端协商完成
+
+	rec := newRecorder(w, req.Method == http.MethodHead, c.maxEntryBytes)
+	fetch(rec, upstreamReq)
+	resp := rec.Response()
+	if resp != nil {
+		c.save(primary, publicPath, policy, upstreamReq.Header, resp, time.Now())
+	}
+	return resp
+}
+
+// revalidate 在后台刷新过期条目，携带 If-None-Match 让上游返回 304 以节省带宽
+func (c *Cache) revalidate(key, primary, publicPath string, req *http.Request, policy Policy, entry *Entry, fetch FetchFunc) {
+	if c.flights.inFlight(key) {
+		return
+	}
+	refreshReq := req.Clone(context.WithoutCancel(req.Context()))
+	refreshReq.Method = http.MethodGet
+	refreshReq.Header.Del("If-Modified-Since")
+	refreshReq.Header.Del("If-None-Match")
+	refreshReq.Header.Del("Accept-Encoding")
+	if entry.ETag != "" {
+		refreshReq.Header.Set("If-None-Match", entry.ETag)
+	}
+
+	go func() {
+		defer func() {
+			if p := recover(); p != nil {
+				c.logger.Error("缓存后台刷新发生 panic", zap.Any("panic", p), zap.String("path", publicPath))
+			}
+		}()
+		c.flights.do(key, func() *Response {
+			rec := newRecorder(nil, false, c.maxEntryBytes)
+			fetch(rec, refreshReq)
+			resp := rec.Response()
+			if resp == nil {
+				return nil // 新响应超过单条上限，不再缓存，旧条目按时效自然过期
+			}
+			if resp.StatusCode == http.StatusNotModified {
+				// 上游确认内容未变：沿用旧响应体，合并新的缓存头后重新计算时效
+				merged := &Response{StatusCode: http.StatusOK, Header: entry.Response.Header.Clone(), Body: entry.Response.Body}
+				for _, h := range []string{"Cache-Control", "Expires", "ETag", "Date"} {
+					if v := resp.Header.Get(h); v != "" {
+						merged.Header.Set(h, v)
+					}
+				}
+				resp = merged
+			}
+			c.save(primary, publicPath, policy, refreshReq.Header, resp, time.Now())
+			return resp
+		})
+	}()
+}
+
+// save 在响应可缓存时写入缓存，并记录上游声明的 Vary 请求头
+// - reqHeader: 触发该响应的请求头，用于计算 Vary 二级键
+func (c *Cache) save(primary, publicPath string, policy Policy, reqHeader http.Header, resp *Response, now time.Time) {
+	if resp == nil || int64(len(resp.Body)) > c.maxEntryBytes {
+		return
+	}
+	fresh := computeFreshness(resp, policy, c.defaultTTL)
+	if !fresh.cacheable {
+		return
+	}
+
+	vary := parseVary(resp.Header.Get("Vary"))
+	c.varies.Store(primary, vary)
+	key := primary + varySuffix(reqHeader, vary)
+
+	c.store.set(key, &Entry{
+		Service:    policy.Service,
+		Path:       publicPath,
+		Response:   resp,
+		ETag:       resp.Header.Get("ETag"),
+		StoredAt:   now,
+		Expires:    now.Add(fresh.ttl),
+		StaleUntil: now.Add(fresh.ttl + fresh.swr),
+		keyBytes:   len(key),
+	})
+}
+
+// Purge 清除缓存条目，service 与 pathPrefix 为空表示不限制，返回清除数量
+func (c *Cache) Purge(service, pathPrefix string) int {
+	removed := c.store.purge(matchPrefix(service, pathPrefix))
+	if service == "" && pathPrefix == "" {
+		c.varies.Range(func(k, _ any) bool {
+			c.varies.Delete(k)
+			return true
+		})
+	}
+	return removed
+}
+
+// Stats 返回缓存统计
+func (c *Cache) Stats() Stats {
+	entries, size := c.store.stats()
+	return Stats{
+		Entries: entries,
+		Bytes:   size,
+		Hits:    c.hits.Load(),
+		Stale:   c.stale.Load(),
+		Misses:  c.misses.Load(),
+	}
+}
+
+// primaryKey 由服务、路径、排序后的查询参数和指定请求头组成
+// - HEAD 与 GET 共享缓存条目
+func primaryKey(req *http.Request, publicPath string, policy Policy) string {
+	var b strings.Builder
+	b.WriteString(policy.Service)
+	b.WriteByte('|')
+	b.WriteString(publicPath)
+	b.WriteByte('?')
+
+	query := req.URL.Query()
+	keys := make([]string, 0, len(query))
+	for k := range query {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		values := query[k]
+		sort.Strings(values)
+		for _, v := range values {
+			b.WriteString(k)
+			b.WriteByte('=')
+			b.WriteString(v)
+			b.WriteByte('&')
+		}
+	}
+	for _, h := range policy.KeyHeaders {
+		b.WriteString("|" + strings.ToLower(h) + "=" + req.Header.Get(h))
+	}
+	return b.String()
+}
+
+// parseVary 解析 Vary 响应头为规范化的请求头名列表（排序去重）
+func parseVary(header string) []string {
+	seen := make(map[string]bool)
+	var names []string
+	for _, part := range strings.Split(header, ",") {
+		name := http.CanonicalHeaderKey(strings.TrimSpace(part))
+		if name == "" || seen[name] || name == "Accept-Encoding" {
+			continue // 回源时已去掉 Accept-Encoding，缓存的始终是未压缩响应
+		}
+		seen[name] = true
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// varySuffix 用请求中 Vary 头的取值拼出二级缓存键
+func varySuffix(reqHeader http.Header, vary []string) string {
+	var b strings.Builder
+	for _, name := range vary {
+		b.WriteString("|v:" + name + "=" + reqHeader.Get(name))
+	}
+	return b.String()
+}
+
+// writeEntry 输出缓存条目，附带 Age 头
+func writeEntry(w http.ResponseWriter, req *http.Request, entry *Entry, status string, now time.Time) {
+	age := strconv.Itoa(int(now.Sub(entry.StoredAt).Seconds()))
+	writeResponse(w, req, entry.Response, status, age)
+}
+
+// writeResponse 输出响应；客户端 If-None-Match 与 ETag 匹配时返回 304
+func writeResponse(w http.ResponseWriter, req *http.Request, resp *Response, cacheStatus, age string) {
+	header := w.Header()
+	for k, vs := range resp.Header {
+		header[k] = append([]string(nil), vs...)
+	}
+	header.Set("X-Cache", cacheStatus)
+	if age != "" {
+		header.Set("Age", age)
+	}
+
+	if etag := resp.Header.Get("ETag"); etag != "" && resp.StatusCode == http.StatusOK && etagMatches(req.Header.Get("If-None-Match"), etag) {
+		header.Del("Content-Length")
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	header.Set("Content-Length", strconv.Itoa(len(resp.Body)))
+	w.WriteHeader(resp.StatusCode)
+	if req.Method != http.MethodHead {
+		_, _ = w.Write(resp.Body)
+	}
+}
+
+// etagMatches 判断 If-None-Match 是否包含给定 ETag（弱比较）
+func etagMatches(ifNoneMatch, etag string) bool {
+	if ifNoneMatch == "" {
+		return false
+	}
+	if strings.TrimSpace(ifNoneMatch) == "*" {
+		return true
+	}
+	target := strings.TrimPrefix(etag, "W/")
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		if strings.TrimPrefix(strings.TrimSpace(candidate), "W/") == target {
+			return true
+		}
+	}
+	return false
+}
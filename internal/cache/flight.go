@@ -0,0 +1,50 @@
+package cache
+
+import "sync"
+
+// flightCall 是一次正在进行中的上游请求
+type flightCall struct {
+	wg  sync.WaitGroup
+	res *Response
+}
+
+// flightGroup 合并相同缓存键的并发未命中请求，只向上游发出一次请求
+type flightGroup struct {
+	mu    sync.Mutex
+	calls map[string]*flightCall
+}
+
+// do 执行 fn 并返回结果；同一 key 已有请求在进行时等待并共享其结果
+// - shared 表示结果来自其他调用方发起的请求
+func (g *flightGroup) do(key string, fn func() *Response) (res *Response, shared bool) {
+	g.mu.Lock()
+	if g.calls == nil {
+		g.calls = make(map[string]*flightCall)
+	}
+	if call, ok := g.calls[key]; ok {
+		g.mu.Unlock()
+		call.wg.Wait()
+		return call.res, true
+	}
+	call := &flightCall{}
+	call.wg.Add(1)
+	g.calls[key] = call
+	g.mu.Unlock()
+
+	defer func() {
+		call.wg.Done()
+		g.mu.Lock()
+		delete(g.calls, key)
+		g.mu.Unlock()
+	}()
+	call.res = fn()
+	return call.res, false
+}
+
+// inFlight 判断 key 是否已有请求在进行
+func (g *flightGroup) inFlight(key string) bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	_, ok := g.calls[key]
+	return ok
+}
@@ -0,0 +1,103 @@
+package cache
+
+import (
+	"container/list"
+	"strings"
+	"sync"
+)
+
+// lruStore 是按字节数限制容量的并发安全 LRU 存储
+type lruStore struct {
+	mu       sync.Mutex
+	maxBytes int64
+	curBytes int64
+	ll       *list.List
+	items    map[string]*list.Element
+}
+
+// lruItem 是链表节点中保存的键值对
+type lruItem struct {
+	key   string
+	entry *Entry
+}
+
+// newLRUStore 创建容量为 maxBytes 的 LRU 存储
+func newLRUStore(maxBytes int64) *lruStore {
+	return &lruStore{
+		maxBytes: maxBytes,
+		ll:       list.New(),
+		items:    make(map[string]*list.Element),
+	}
+}
+
+// get 读取条目并将其移到最近使用位置
+func (s *lruStore) get(key string) (*Entry, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if el, ok := s.items[key]; ok {
+		s.ll.MoveToFront(el)
+		return el.Value.(*lruItem).entry, true
+	}
+	return nil, false
+}
+
+// set 写入条目，超出容量时从最久未使用的条目开始淘汰
+func (s *lruStore) set(key string, entry *Entry) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if el, ok := s.items[key]; ok {
+		s.curBytes -= el.Value.(*lruItem).entry.size()
+		el.Value.(*lruItem).entry = entry
+		s.ll.MoveToFront(el)
+	} else {
+		s.items[key] = s.ll.PushFront(&lruItem{key: key, entry: entry})
+	}
+	s.curBytes += entry.size()
+
+	for s.curBytes > s.maxBytes && s.ll.Len() > 0 {
+		s.removeElement(s.ll.Back())
+	}
+}
+
+// purge 删除满足条件的条目，返回删除数量；match 为 nil 时清空全部
+func (s *lruStore) purge(match func(*Entry) bool) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	removed := 0
+	for el := s.ll.Front(); el != nil; {
+		next := el.Next()
+		if match == nil || match(el.Value.(*lruItem).entry) {
+			s.removeElement(el)
+			removed++
+		}
+		el = next
+	}
+	return removed
+}
+
+// stats 返回当前条目数与占用字节数
+func (s *lruStore) stats() (int, int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.ll.Len(), s.curBytes
+}
+
+// removeElement 删除链表节点并更新占用字节数，调用方需持有锁
+func (s *lruStore) removeElement(el *list.Element) {
+	item := el.Value.(*lruItem)
+	s.ll.Remove(el)
+	delete(s.items, item.key)
+	s.curBytes -= item.entry.size()
+}
+
+// matchPrefix 返回按服务名与路径前缀筛选条目的函数，空参数表示不限制
+func matchPrefix(service, pathPrefix string) func(*Entry) bool {
+	if service == "" && pathPrefix == "" {
+		return nil
+	}
+	return func(e *Entry) bool {
+		return (service == "" || e.Service == service) && strings.HasPrefix(e.Path, pathPrefix)
+	}
+}
@@ -0,0 +1,193 @@
+package cache
+
+import (
+	"bytes"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Response 是一次完整的上游响应
+type Response struct {
+	StatusCode int
+	Header     http.Header
+	Body       []byte
+}
+
+// Recorder 是回源时使用的 http.ResponseWriter：响应体不超过 limit 时完整记录在内存中，供写入缓存
+// - 超过 limit 后不再缓冲：把已记录的响应头与响应体前缀写给客户端，其余部分直接转发，该响应不可缓存
+// - 客户端为 nil（后台刷新）时超出部分被丢弃
+type Recorder struct {
+	status   int
+	header   http.Header
+	body     bytes.Buffer
+	limit    int64
+	client   http.ResponseWriter
+	headOnly bool // 客户端请求为 HEAD：直接转发时只写响应头
+	streamed bool // 响应体超过 limit，已转为直接转发
+}
+
+// newRecorder 创建响应记录器
+func newRecorder(client http.ResponseWriter, headOnly bool, limit int64) *Recorder {
+	return &Recorder{header: make(http.Header), limit: limit, client: client, headOnly: headOnly}
+}
+
+// Header 实现 http.ResponseWriter
+func (r *Recorder) Header() http.Header { return r.header }
+
+// WriteHeader 实现 http.ResponseWriter
+func (r *Recorder) WriteHeader(status int) {
+	if r.status == 0 {
+		r.status = status
+	}
+}
+
+// Write 实现 http.ResponseWriter
+func (r *Recorder) Write(p []byte) (int, error) {
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+	if r.streamed {
+		return r.writeClient(p)
+	}
+	if int64(r.body.Len()+len(p)) <= r.limit {
+		return r.body.Write(p)
+	}
+
+	r.streamed = true
+	if r.client != nil {
+		header := r.client.Header()
+		for k, vs := range r.header {
+			header[k] = append([]string(nil), vs...)
+		}
+		header.Set("X-Cache", "MISS")
+		r.client.WriteHeader(r.status)
+	}
+	prefix := r.body.Bytes()
+	r.body = bytes.Buffer{}
+	if _, err := r.writeClient(prefix); err != nil {
+		return 0, err
+	}
+	return r.writeClient(p)
+}
+
+// writeClient 把响应体直接写给客户端
+func (r *Recorder) writeClient(p []byte) (int, error) {
+	if r.client == nil || r.headOnly {
+		return len(p), nil
+	}
+	return r.client.Write(p)
+}
+
+// Flush 实现 http.Flusher：缓冲期间无需刷新，转为直接转发后刷新客户端连接
+func (r *Recorder) Flush() {
+	if f, ok := r.client.(http.Flusher); ok && r.streamed {
+		f.Flush()
+	}
+}
+
+// Response 返回记录到的完整响应；响应体超过上限、已直接转发给客户端时返回 nil
+func (r *Recorder) Response() *Response {
+	if r.streamed {
+		return nil
+	}
+	status := r.status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	return &Response{StatusCode: status, Header: r.header, Body: r.body.Bytes()}
+}
+
+// Entry 是一条缓存记录
+type Entry struct {
+	Service    string
+	Path       string
+	Response   *Response
+	ETag       string
+	StoredAt   time.Time
+	Expires    time.Time // 新鲜期截止时间
+	StaleUntil time.Time // 允许返回旧响应并后台刷新的截止时间
+	keyBytes   int
+}
+
+// size 估算条目占用的字节数
+func (e *Entry) size() int64 {
+	n := len(e.Response.Body) + e.keyBytes + len(e.Path)
+	for k, vs := range e.Response.Header {
+		n += len(k)
+		for _, v := range vs {
+			n += len(v)
+		}
+	}
+	return int64(n)
+}
+
+// freshness 是由上游 Cache-Control 与路由规则得出的缓存时效
+type freshness struct {
+	cacheable bool
+	ttl       time.Duration
+	swr       time.Duration
+}
+
+// computeFreshness 解析上游响应的缓存指令，并应用路由规则的覆盖值
+// - no-store / private / no-cache、Set-Cookie、Vary: * 与非 200 状态码均不缓存
+// - s-maxage 优先于 max-age；路由 TTL 覆盖上游 max-age；路由 StaleWhileRevalidate 覆盖上游同名指令
+func computeFreshness(resp *Response, policy Policy, defaultTTL time.Duration) freshness {
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("Set-Cookie") != "" || resp.Header.Get("Vary") == "*" {
+		return freshness{}
+	}
+
+	directives := parseCacheControl(resp.Header.Get("Cache-Control"))
+	for _, d := range []string{"no-store", "private", "no-cache"} {
+		if _, ok := directives[d]; ok {
+			return freshness{}
+		}
+	}
+
+	ttl, hasTTL := defaultTTL, false
+	if v, ok := directives["s-maxage"]; ok {
+		ttl, hasTTL = parseSeconds(v)
+	} else if v, ok := directives["max-age"]; ok {
+		ttl, hasTTL = parseSeconds(v)
+	}
+	if !hasTTL {
+		ttl = defaultTTL
+	}
+	if policy.TTL > 0 {
+		ttl = policy.TTL
+	}
+
+	var swr time.Duration
+	if v, ok := directives["stale-while-revalidate"]; ok {
+		swr, _ = parseSeconds(v)
+	}
+	if policy.StaleWhileRevalidate > 0 {
+		swr = policy.StaleWhileRevalidate
+	}
+
+	return freshness{cacheable: ttl > 0, ttl: ttl, swr: swr}
+}
+
+// parseCacheControl 把 Cache-Control 头解析为小写指令到值的映射
+func parseCacheControl(header string) map[string]string {
+	directives := make(map[string]string)
+	for _, part := range strings.Split(header, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		name, value, _ := strings.Cut(part, "=")
+		directives[strings.ToLower(strings.TrimSpace(name))] = strings.Trim(strings.TrimSpace(value), `"`)
+	}
+	return directives
+}
+
+// parseSeconds 把秒数字符串解析为时长
+func parseSeconds(v string) (time.Duration, bool) {
+	n, err := strconv.Atoi(v)
+	if err != nil || n < 0 {
+		return 0, false
+	}
+	return time.Duration(n) * time.Second, true
+}
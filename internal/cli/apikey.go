@@ -0,0 +1,150 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/Xushengqwer/gateway/internal/apikey"
+	"github.com/Xushengqwer/go-common/models/enums"
+)
+
+const apiKeyUsage = `用法: gateway apikey <create|list|rotate|revoke> [flags]
+
+  create -owner partner-a -role 1 [-scopes search:read,post:read] [-services search-service] [-plan partner-basic] [-expires 720h]
+  list
+  rotate -id <keyID> [-grace 24h]
+  revoke -id <keyID>
+
+所有子命令都接受 -config（默认 ./config/development.yaml），存储文件取自配置中的 apiKeys.storeFile`
+
+// RunAPIKey 实现 `gateway apikey` 子命令，直接读写 apiKeys.storeFile
+// - 运行中的网关会在几秒内自动加载变更，无需重启
+// - 返回进程退出码：0 表示成功
+func RunAPIKey(args []string) int {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, apiKeyUsage)
+		return 2
+	}
+	action := args[0]
+	fs := flag.NewFlagSet("apikey "+action, flag.ContinueOnError)
+	configFile := fs.String("config", "./config/development.yaml", "Path to the configuration file.")
+	owner := fs.String("owner", "", "Key 持有者（create）")
+	role := fs.Uint("role", uint(enums.RoleUser), "角色：0 管理员 / 1 普通用户 / 2 访客（create）")
+	scopes := fs.String("scopes", "", "逗号分隔的授权范围（create）")
+	services := fs.String("services", "", "逗号分隔的允许访问的服务名，为空表示不限制（create）")
+	plan := fs.String("plan", "", "限流套餐名（create）")
+	expires := fs.Duration("expires", 0, "有效期，0 表示永不过期（create）")
+	id := fs.String("id", "", "Key ID（rotate / revoke）")
+	grace := fs.Duration("grace", 0, "轮换后旧 Key 继续有效的时长（rotate）")
+	if err := fs.Parse(args[1:]); err != nil {
+		return 2
+	}
+
+	cfg, err := loadConfig(*configFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "加载配置失败: %v\n", err)
+		return 1
+	}
+	if cfg.APIKeys == nil || cfg.APIKeys.StoreFile == "" {
+		fmt.Fprintln(os.Stderr, "配置中未启用 apiKeys.storeFile")
+		return 1
+	}
+	store, err := apikey.Open(cfg.APIKeys.StoreFile)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	switch action {
+	case "create":
+		opts := apikey.CreateOptions{
+			Owner:    *owner,
+			Role:     enums.UserRole(*role),
+			Scopes:   splitList(*scopes),
+			Services: splitList(*services),
+			Plan:     *plan,
+		}
+		if *expires > 0 {
+			at := time.Now().UTC().Add(*expires)
+			opts.ExpiresAt = &at
+		}
+		if err := cfg.CheckAPIKeyGrant(opts.Plan, opts.Services); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		key, plaintext, err := store.Create(opts)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		printIssued(os.Stdout, key, plaintext)
+	case "list":
+		printKeys(os.Stdout, store.List())
+	case "rotate":
+		key, plaintext, err := store.Rotate(*id, *grace)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		printIssued(os.Stdout, key, plaintext)
+	case "revoke":
+		key, err := store.Revoke(*id)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		fmt.Fprintf(os.Stdout, "已吊销 %s (%s)\n", key.ID, key.Owner)
+	default:
+		fmt.Fprintln(os.Stderr, apiKeyUsage)
+		return 2
+	}
+	return 0
+}
+
+// printIssued 输出新签发的 Key，明文只显示这一次
+func printIssued(w io.Writer, key apikey.Key, plaintext string) {
+	fmt.Fprintf(w, "ID:     %s\nOwner:  %s\nAPIKey: %s\n\n请立即保存 APIKey，网关只保存其哈希，之后无法再次查看。\n", key.ID, key.Owner, plaintext)
+}
+
+// printKeys 以表格输出 Key 列表
+func printKeys(w io.Writer, keys []apikey.Key) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tOWNER\tROLE\tPLAN\tSERVICES\tSCOPES\tSTATUS\tCREATED")
+	now := time.Now()
+	for _, k := range keys {
+		status := "active"
+		switch {
+		case k.RevokedAt != nil:
+			status = "revoked"
+		case !k.Active(now):
+			status = "expired"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n", k.ID, k.Owner, k.Role.String(), orDash(k.Plan),
+			orDash(strings.Join(k.Services, ",")), orDash(strings.Join(k.Scopes, ",")), status, k.CreatedAt.Format(time.RFC3339))
+	}
+	tw.Flush()
+}
+
+// splitList 把逗号分隔的参数拆分为列表，忽略空项
+func splitList(s string) []string {
+	var out []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			out = append(out, item)
+		}
+	}
+	return out
+}
+
+// orDash 把空串显示为 "-"
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
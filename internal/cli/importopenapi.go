@@ -0,0 +1,228 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Xushengqwer/gateway/internal/config"
+	"github.com/Xushengqwer/gateway/internal/openapi"
+
+	"gopkg.in/yaml.v3"
+)
+
+const importOpenAPIUsage = `用法: gateway import-openapi -service <服务名> [-spec <文件或地址>] [flags]
+
+  生成服务配置:  gateway import-openapi -service post-service -spec ./post.swagger.json [-prefix /api/v1/post] [-min-role user] [-o post.yaml]
+  检查配置漂移:  gateway import-openapi -service post-service -diff
+
+-spec 省略时使用配置中该服务的 openapi 来源；-config 默认 ./config/development.yaml`
+
+// specFetchTimeout 是从地址拉取文档的超时
+const specFetchTimeout = 10 * time.Second
+
+// RunImportOpenAPI 实现 `gateway import-openapi` 子命令
+//   - 默认模式按文档生成服务的 routes / publicPaths，输出可直接放入 services 列表的 YAML；
+//     配置中已有同名服务时沿用其上游等设置，只替换 routes 与 publicPaths
+//   - -diff 模式对比文档与配置中该服务的当前策略，输出缺失、不一致与多余的规则
+//
+// 返回进程退出码：0 表示成功（-diff 时表示没有漂移），1 表示失败或存在漂移
+func RunImportOpenAPI(args []string) int {
+	fs := flag.NewFlagSet("import-openapi", flag.ContinueOnError)
+	configFile := fs.String("config", "./config/development.yaml", "Path to the configuration file.")
+	spec := fs.String("spec", "", "OpenAPI / Swagger 文档的文件路径或 http(s) 地址")
+	service := fs.String("service", "", "服务名")
+	prefix := fs.String("prefix", "", "服务前缀，默认沿用配置或使用文档的基础路径")
+	basePath := fs.String("base-path", "", "覆盖文档中的 basePath / servers 路径")
+	minRole := fs.String("min-role", "user", "需要认证但文档未声明角色的操作使用的最低角色")
+	diff := fs.Bool("diff", false, "只对比文档与当前配置，不生成配置")
+	output := fs.String("o", "", "生成的配置写入该文件，默认输出到标准输出")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if *service == "" {
+		fmt.Fprintln(os.Stderr, importOpenAPIUsage)
+		return 2
+	}
+
+	cfg, err := loadConfig(*configFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "加载配置失败: %v\n", err)
+		return 1
+	}
+	roles, err := cfg.RoleHierarchy()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "角色定义无效: %v\n", err)
+		return 1
+	}
+	var existing *config.ServiceConfig
+	for i := range cfg.Services {
+		if cfg.Services[i].Name == *service {
+			existing = &cfg.Services[i]
+			break
+		}
+	}
+
+	location := *spec
+	if location == "" {
+		if location, err = specLocation(existing); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), specFetchTimeout)
+	defer cancel()
+	doc, err := openapi.Load(ctx, &http.Client{Timeout: specFetchTimeout}, location)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "加载文档失败: %v\n", err)
+		return 1
+	}
+
+	if *diff {
+		if existing == nil {
+			fmt.Fprintf(os.Stderr, "配置中没有服务 %q\n", *service)
+			return 1
+		}
+		if err := cfg.Validate(); err != nil {
+			fmt.Fprintf(os.Stderr, "❌ 配置校验失败:\n%v\n", err)
+			return 1
+		}
+		override := *basePath
+		if override == "" && existing.OpenAPI != nil {
+			override = existing.OpenAPI.BasePath
+		}
+		return printDrifts(os.Stdout, existing.Name, location, openapi.Diff(doc, existing, roles, override))
+	}
+
+	if !roles.Has(*minRole) {
+		fmt.Fprintf(os.Stderr, "未定义的角色 %q (可用: %s)\n", *minRole, strings.Join(roles.Names(), ", "))
+		return 1
+	}
+	svc := config.ServiceConfig{Name: *service}
+	if existing != nil {
+		svc = *existing
+	} else {
+		svc.OpenAPI = specSource(location)
+	}
+	if *prefix != "" {
+		svc.Prefix = *prefix
+	}
+	for _, w := range openapi.Import(doc, &svc, openapi.ImportOptions{BasePath: *basePath, MinRole: *minRole}) {
+		fmt.Fprintf(os.Stderr, "警告: %s\n", w)
+	}
+
+	out := io.Writer(os.Stdout)
+	if *output != "" {
+		f, err := os.Create(*output)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		defer f.Close()
+		out = f
+	}
+	if err := writeServiceYAML(out, location, &svc); err != nil {
+		fmt.Fprintf(os.Stderr, "输出配置失败: %v\n", err)
+		return 1
+	}
+	if existing == nil {
+		fmt.Fprintln(os.Stderr, "提示: 请补充 host / port（或 serviceName）后加入 services 列表，并用 `gateway validate` 校验")
+	} else {
+		fmt.Fprintln(os.Stderr, "提示: 只输出了上游地址与访问策略，超时、镜像、缓存等其他设置请沿用现有配置")
+	}
+	return 0
+}
+
+// specLocation 返回配置中服务的文档来源：文件、完整地址，或按 host / port 拼接的上游地址
+func specLocation(svc *config.ServiceConfig) (string, error) {
+	if svc == nil || svc.OpenAPI == nil {
+		return "", errors.New("请通过 -spec 指定文档（配置中该服务未设置 openapi）")
+	}
+	src := svc.OpenAPI
+	switch {
+	case src.File != "":
+		return src.File, nil
+	case !strings.HasPrefix(src.URL, "/"):
+		return src.URL, nil
+	case svc.Host != "" && svc.Port != 0:
+		scheme := svc.Scheme
+		if scheme == "" {
+			scheme = "http"
+		}
+		return scheme + "://" + svc.Host + ":" + strconv.Itoa(svc.Port) + src.URL, nil
+	}
+	return "", fmt.Errorf("服务 %q 的文档地址 %s 相对上游，无法离线确定上游地址，请通过 -spec 指定", svc.Name, src.URL)
+}
+
+// specSource 把命令行指定的文档来源写入新生成的服务配置，便于之后用 -diff 与聚合文档复用
+func specSource(location string) *config.OpenAPISource {
+	if u, err := url.Parse(location); err == nil && (u.Scheme == "http" || u.Scheme == "https") {
+		return &config.OpenAPISource{URL: location}
+	}
+	return &config.OpenAPISource{File: location}
+}
+
+// writeServiceYAML 以单元素列表输出服务配置，可直接粘贴到 services 下
+// - 只输出上游地址与访问策略相关的字段，超时、镜像、缓存等其他设置请沿用现有配置
+func writeServiceYAML(w io.Writer, location string, svc *config.ServiceConfig) error {
+	out := config.ServiceConfig{
+		Name:           svc.Name,
+		Host:           svc.Host,
+		Port:           svc.Port,
+		ServiceName:    svc.ServiceName,
+		Namespace:      svc.Namespace,
+		Scheme:         svc.Scheme,
+		Prefix:         svc.Prefix,
+		StripPrefix:    svc.StripPrefix,
+		UpstreamPrefix: svc.UpstreamPrefix,
+		OpenAPI:        svc.OpenAPI,
+		Auth:           svc.Auth,
+		DefaultPolicy:  svc.DefaultPolicy,
+		Deny:           svc.Deny,
+		PublicPaths:    svc.PublicPaths,
+		Routes:         svc.Routes,
+	}
+	fmt.Fprintf(w, "# 由 gateway import-openapi 根据 %s 生成\n", location)
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode([]*config.ServiceConfig{&out}); err != nil {
+		return err
+	}
+	return enc.Close()
+}
+
+// printDrifts 输出差异，返回退出码：存在计入漂移的差异时为 1
+func printDrifts(w io.Writer, service, location string, drifts []openapi.Drift) int {
+	counted := 0
+	for _, d := range drifts {
+		if d.Counts() {
+			counted++
+		}
+	}
+	if len(drifts) == 0 {
+		fmt.Fprintf(w, "✅ 服务 %s 的配置与文档 %s 一致\n", service, location)
+		return 0
+	}
+
+	fmt.Fprintf(w, "服务 %s 与文档 %s 的差异:\n", service, location)
+	for _, d := range drifts {
+		target := d.Path
+		if d.Method != "" {
+			target = d.Method + " " + d.Path
+		}
+		fmt.Fprintf(w, "  %-9s %s: %s\n", d.Kind, target, d.Message)
+	}
+	fmt.Fprintf(w, "\n%d 处漂移，%d 条提示\n", counted, len(drifts)-counted)
+	if counted > 0 {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,268 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/Xushengqwer/gateway/internal/auth"
+	"github.com/Xushengqwer/gateway/internal/config"
+	"github.com/Xushengqwer/gateway/internal/router"
+	"github.com/Xushengqwer/go-common/models/enums"
+
+	"github.com/gin-gonic/gin"
+	"gopkg.in/yaml.v3"
+)
+
+// policyCaseFile 是策略测试用例文件
+//
+//	cases:
+//	  - name: 普通用户不能审核帖子
+//	    method: POST
+//	    path: /api/v1/post/admin/posts/audit
+//	    claims: {role: user}
+//	    expect: denied
+//	  - name: 匿名用户可以登录
+//	    method: POST
+//	    path: /api/v1/user-hub/account/login
+//	    anonymous: true
+//	    expect: public
+type policyCaseFile struct {
+	Cases []policyCase `yaml:"cases"`
+}
+
+// policyCase 是一条策略测试用例
+// - claims 与 anonymous 二选一，都未设置时视为匿名
+// - expect 取 public / allowed / denied / notfound（404 同 notfound），status 可选，用于进一步断言 401 / 403
+type policyCase struct {
+	Name      string            `yaml:"name"`
+	Method    string            `yaml:"method"`
+	Path      string            `yaml:"path"`
+	Host      string            `yaml:"host"`
+	Headers   map[string]string `yaml:"headers"`
+	Claims    *policyClaims     `yaml:"claims"`
+	Anonymous bool              `yaml:"anonymous"`
+	Expect    string            `yaml:"expect"`
+	Status    int               `yaml:"status"`
+}
+
+// policyClaims 描述测试身份，role 可以是角色名或数值
+type policyClaims struct {
+	UserID   string   `yaml:"userID"`
+	Role     string   `yaml:"role"`
+	Status   string   `yaml:"status"`   // active / blacklisted，默认 active
+	Platform string   `yaml:"platform"` // JWT 身份的平台，设置后与请求的 X-Platform 请求头（或路径前缀）比较，不设置则不校验
+	Scheme   string   `yaml:"scheme"`   // 默认 jwt
+	Scopes   []string `yaml:"scopes"`
+}
+
+// RunTestPolicy 实现 `gateway test-policy` 子命令
+// - 读取配置与 YAML 用例，按网关运行时的匹配与权限逻辑离线判断每条用例，不访问上游
+// - 返回进程退出码：0 表示全部通过，1 表示存在失败用例或配置无效
+func RunTestPolicy(args []string) int {
+	fs := flag.NewFlagSet("test-policy", flag.ContinueOnError)
+	configFile := fs.String("config", "./config/development.yaml", "Path to the configuration file.")
+	casesFile := fs.String("cases", "", "策略测试用例 YAML 文件")
+	verbose := fs.Bool("v", false, "同时输出通过的用例")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if *casesFile == "" {
+		fmt.Fprintln(os.Stderr, "用法: gateway test-policy -config <配置文件> -cases <用例文件> [-v]")
+		return 2
+	}
+
+	cfg, err := loadConfig(*configFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "加载配置失败: %v\n", err)
+		return 1
+	}
+	if err := cfg.Validate(); err != nil {
+		fmt.Fprintf(os.Stderr, "❌ 配置校验失败:\n%v\n", err)
+		return 1
+	}
+	cases, err := loadPolicyCases(*casesFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "加载用例失败: %v\n", err)
+		return 1
+	}
+	roles, err := cfg.RoleHierarchy()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "角色定义无效: %v\n", err)
+		return 1
+	}
+	gin.SetMode(gin.ReleaseMode) // 离线判断借用 gin.Context，不输出调试信息
+	tester, err := router.NewPolicyTester(cfg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "创建策略判断器失败: %v\n", err)
+		return 1
+	}
+
+	failed := 0
+	for i, tc := range cases {
+		label := tc.Name
+		if label == "" {
+			label = fmt.Sprintf("cases[%d]", i)
+		}
+		result, err := runPolicyCase(tester, roles, tc)
+		switch {
+		case err != nil:
+			failed++
+			fmt.Printf("FAIL  %s: %v\n", label, err)
+		case !policyCaseMatches(tc, result):
+			failed++
+			fmt.Printf("FAIL  %s: %s %s 期望 %s，实际 %s\n", label, strings.ToUpper(tc.Method), tc.Path, describeExpect(tc), describeResult(result))
+		case *verbose:
+			fmt.Printf("ok    %s: %s\n", label, describeResult(result))
+		}
+	}
+
+	fmt.Printf("\n%d 条用例，%d 条通过，%d 条失败\n", len(cases), len(cases)-failed, failed)
+	if failed > 0 {
+		return 1
+	}
+	return 0
+}
+
+// loadPolicyCases 读取并检查用例文件
+func loadPolicyCases(path string) ([]policyCase, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var file policyCaseFile
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, err
+	}
+	for i, tc := range file.Cases {
+		if tc.Path == "" || tc.Expect == "" {
+			return nil, fmt.Errorf("cases[%d](%s): path 与 expect 不能为空", i, tc.Name)
+		}
+		if tc.Claims != nil && tc.Anonymous {
+			return nil, fmt.Errorf("cases[%d](%s): claims 与 anonymous 不能同时设置", i, tc.Name)
+		}
+		switch normalizeExpect(tc.Expect) {
+		case router.OutcomePublic, router.OutcomeAllowed, router.OutcomeDenied, router.OutcomeNotFound:
+		default:
+			return nil, fmt.Errorf("cases[%d](%s): expect 仅支持 public / allowed / denied / notfound: %s", i, tc.Name, tc.Expect)
+		}
+	}
+	return file.Cases, nil
+}
+
+// runPolicyCase 构造请求与身份并判断
+func runPolicyCase(tester *router.PolicyTester, roles *config.RoleHierarchy, tc policyCase) (router.PolicyResult, error) {
+	method := strings.ToUpper(tc.Method)
+	if method == "" {
+		method = http.MethodGet
+	}
+	req := httptest.NewRequest(method, tc.Path, nil)
+	if tc.Host != "" {
+		req.Host = tc.Host
+	}
+	for k, v := range tc.Headers {
+		req.Header.Set(k, v)
+	}
+
+	var principal *auth.Principal
+	if tc.Claims != nil {
+		p, err := claimsPrincipal(roles, tc.Claims)
+		if err != nil {
+			return router.PolicyResult{}, err
+		}
+		principal = p
+	}
+	return tester.Evaluate(req, principal), nil
+}
+
+// claimsPrincipal 把用例中的 claims 转换为认证身份
+func claimsPrincipal(roles *config.RoleHierarchy, claims *policyClaims) (*auth.Principal, error) {
+	p := &auth.Principal{
+		ID:       claims.UserID,
+		Status:   enums.StatusActive,
+		Scheme:   claims.Scheme,
+		Platform: claims.Platform,
+		Scopes:   claims.Scopes,
+	}
+	if p.ID == "" {
+		p.ID = "policy-test-user"
+	}
+	if p.Scheme == "" {
+		p.Scheme = config.AuthSchemeJWT
+	}
+
+	switch {
+	case claims.Role == "":
+		p.Role = enums.RoleGuest
+	default:
+		if v, ok := roles.Value(claims.Role); ok {
+			p.Role = v
+		} else if n, err := strconv.ParseUint(claims.Role, 10, 32); err == nil {
+			p.Role = enums.UserRole(n)
+		} else {
+			return nil, fmt.Errorf("未定义的角色 %q (可用: %s)", claims.Role, strings.Join(roles.Names(), ", "))
+		}
+	}
+
+	switch strings.ToLower(claims.Status) {
+	case "", "active":
+	case "blacklisted":
+		p.Status = enums.StatusBlacklisted
+	default:
+		return nil, fmt.Errorf("status 仅支持 active / blacklisted: %s", claims.Status)
+	}
+	return p, nil
+}
+
+// normalizeExpect 统一 expect 写法："404" 等同于 notfound
+func normalizeExpect(expect string) string {
+	expect = strings.ToLower(strings.TrimSpace(expect))
+	if expect == "404" {
+		return router.OutcomeNotFound
+	}
+	return expect
+}
+
+func policyCaseMatches(tc policyCase, r router.PolicyResult) bool {
+	if normalizeExpect(tc.Expect) != r.Outcome {
+		return false
+	}
+	return tc.Status == 0 || tc.Status == r.Status
+}
+
+func describeExpect(tc policyCase) string {
+	if tc.Status != 0 {
+		return fmt.Sprintf("%s (%d)", normalizeExpect(tc.Expect), tc.Status)
+	}
+	return normalizeExpect(tc.Expect)
+}
+
+// describeResult 输出结果、状态码与作出判断的规则
+func describeResult(r router.PolicyResult) string {
+	var b strings.Builder
+	b.WriteString(r.Outcome)
+	if r.Status != 0 && !r.Shadow {
+		fmt.Fprintf(&b, " (%d)", r.Status)
+	}
+	if r.Service != "" {
+		fmt.Fprintf(&b, " [%s", r.Service)
+		if r.Rule != "" {
+			b.WriteString(" / " + r.Rule)
+		}
+		if r.Route != "" {
+			b.WriteString(" (" + r.Route + ")")
+		}
+		b.WriteString("]")
+	}
+	if r.Shadow {
+		fmt.Fprintf(&b, " (试运行规则本应返回 %d)", r.Status)
+	}
+	if r.Message != "" && !r.Shadow && r.Outcome != router.OutcomePublic && r.Outcome != router.OutcomeAllowed {
+		b.WriteString(": " + r.Message)
+	}
+	return b.String()
+}
@@ -0,0 +1,228 @@
+package compress
+
+import (
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/Xushengqwer/gateway/internal/config"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Compressor 按服务的压缩配置处理响应压缩与请求体解压
+type Compressor struct {
+	cfg *config.CompressionConfig
+}
+
+// New 根据合并后的压缩配置创建 Compressor；压缩与解压均未启用时返回 nil
+func New(cfg *config.CompressionConfig) *Compressor {
+	if !cfg.CompressEnabled() && !cfg.DecompressEnabled() {
+		return nil
+	}
+	return &Compressor{cfg: cfg}
+}
+
+// Wrap 在客户端接受压缩时返回包装后的 ResponseWriter，否则返回 nil
+// - 调用方必须在处理结束后调用 Close 输出缓冲内容并释放压缩器
+func (cp *Compressor) Wrap(w gin.ResponseWriter, req *http.Request) *Writer {
+	if !cp.cfg.CompressEnabled() || req.Method == http.MethodHead {
+		return nil
+	}
+	encoding := Negotiate(req.Header.Get("Accept-Encoding"), cp.cfg.Algorithms)
+	if encoding == "" {
+		return nil
+	}
+	return &Writer{ResponseWriter: w, cfg: cp.cfg, encoding: encoding}
+}
+
+// DecompressRequest 在启用请求体解压时，把带 Content-Encoding 的请求体替换为解压后的流
+// - 解压后的大小受 maxDecompressedBytes 限制，超出时读取请求体返回 *http.MaxBytesError
+// - 返回 ErrUnsupportedEncoding 或 ErrInvalidBody 时调用方应拒绝请求
+func (cp *Compressor) DecompressRequest(w http.ResponseWriter, req *http.Request) error {
+	if !cp.cfg.DecompressEnabled() || req.Body == nil || req.Body == http.NoBody {
+		return nil
+	}
+	encoding := strings.ToLower(strings.TrimSpace(req.Header.Get("Content-Encoding")))
+	if encoding == "" || encoding == "identity" {
+		return nil
+	}
+	body, err := newDecoder(encoding, req.Body, cp.cfg.MaxDecompressedBytes)
+	if err != nil {
+		return err
+	}
+	req.Body = http.MaxBytesReader(w, body, cp.cfg.MaxDecompressedBytes)
+	req.ContentLength = -1
+	req.Header.Del("Content-Encoding")
+	req.Header.Del("Content-Length")
+	return nil
+}
+
+// Writer 是按需压缩响应体的 gin.ResponseWriter
+// - 在响应头确定后判断是否压缩；长度未知时先缓冲至 minSize 再决定
+// - 流式响应在缓冲未满时被 Flush 即放弃压缩，保证实时性
+type Writer struct {
+	gin.ResponseWriter
+	cfg      *config.CompressionConfig
+	encoding string
+
+	status  int
+	decided bool
+	enc     encoder // 决定压缩后非 nil
+	buf     []byte
+}
+
+// WriteHeader 记录状态码，能够直接判断时立即决定是否压缩
+func (w *Writer) WriteHeader(code int) {
+	if w.status != 0 {
+		return
+	}
+	w.status = code
+	if !w.eligible() {
+		w.passthrough()
+		return
+	}
+	if cl := w.Header().Get("Content-Length"); cl != "" {
+		// 长度已知：足够大就直接压缩，否则原样输出
+		if n, err := strconv.ParseInt(cl, 10, 64); err == nil && n < int64(w.cfg.MinSize) {
+			w.passthrough()
+		} else {
+			w.startCompression()
+		}
+	}
+}
+
+// WriteHeaderNow 实现 gin.ResponseWriter，尚未决定时按当前状态码完成决定
+func (w *Writer) WriteHeaderNow() {
+	if w.status == 0 {
+		w.WriteHeader(http.StatusOK)
+	}
+	if !w.decided {
+		w.passthrough()
+	}
+	w.ResponseWriter.WriteHeaderNow()
+}
+
+// Write 写入响应体
+func (w *Writer) Write(p []byte) (int, error) {
+	if w.status == 0 {
+		w.WriteHeader(http.StatusOK)
+	}
+	if w.decided {
+		if w.enc != nil {
+			return w.enc.Write(p)
+		}
+		return w.ResponseWriter.Write(p)
+	}
+	w.buf = append(w.buf, p...)
+	if len(w.buf) >= w.cfg.MinSize {
+		w.startCompression()
+	}
+	return len(p), nil
+}
+
+// WriteString 写入字符串响应体
+func (w *Writer) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+// Flush 刷新已压缩的数据；尚未决定时视为流式响应，放弃压缩
+func (w *Writer) Flush() {
+	if !w.decided {
+		if w.status == 0 {
+			w.status = http.StatusOK
+		}
+		w.passthrough()
+	}
+	if w.enc != nil {
+		_ = w.enc.Flush()
+	}
+	w.ResponseWriter.Flush()
+}
+
+// Status 返回记录的状态码
+func (w *Writer) Status() int {
+	if w.status != 0 {
+		return w.status
+	}
+	return w.ResponseWriter.Status()
+}
+
+// Close 输出仍在缓冲的响应体并结束压缩流
+func (w *Writer) Close() {
+	if w.status == 0 {
+		return // 未写入任何内容，交由上层处理
+	}
+	if !w.decided {
+		w.passthrough()
+	}
+	if w.enc != nil {
+		_ = w.enc.Close()
+		releaseEncoder(w.encoding, w.cfg.Level, w.enc)
+		w.enc = nil
+	}
+}
+
+// eligible 根据状态码与响应头判断响应是否可压缩
+func (w *Writer) eligible() bool {
+	switch {
+	case w.status < http.StatusOK, w.status == http.StatusNoContent, w.status == http.StatusNotModified,
+		w.status == http.StatusPartialContent:
+		return false
+	}
+	header := w.Header()
+	if ce := header.Get("Content-Encoding"); ce != "" && !strings.EqualFold(ce, "identity") {
+		return false // 上游已压缩
+	}
+	if header.Get("Content-Range") != "" || strings.Contains(strings.ToLower(header.Get("Cache-Control")), "no-transform") {
+		return false
+	}
+	mediaType, _, err := mime.ParseMediaType(header.Get("Content-Type"))
+	if err != nil || mediaType == "text/event-stream" {
+		return false // 流式响应必须逐条实时下发
+	}
+	return typeAllowed(mediaType, w.cfg.ContentTypes)
+}
+
+// startCompression 写出压缩响应头并把缓冲内容写入压缩器
+func (w *Writer) startCompression() {
+	w.decided = true
+	header := w.Header()
+	header.Del("Content-Length")
+	header.Set("Content-Encoding", w.encoding)
+	header.Add("Vary", "Accept-Encoding")
+	if etag := header.Get("ETag"); etag != "" && !strings.HasPrefix(etag, "W/") {
+		header.Set("ETag", "W/"+etag) // 压缩后字节不同，强 ETag 降级为弱 ETag
+	}
+	w.ResponseWriter.WriteHeader(w.status)
+	w.enc = acquireEncoder(w.encoding, w.cfg.Level, w.ResponseWriter)
+	if len(w.buf) > 0 {
+		_, _ = w.enc.Write(w.buf)
+		w.buf = nil
+	}
+}
+
+// passthrough 放弃压缩，原样写出响应头与缓冲内容
+func (w *Writer) passthrough() {
+	w.decided = true
+	if w.eligible() {
+		w.Header().Add("Vary", "Accept-Encoding") // 响应本可压缩，只因体积过小或流式而未压缩
+	}
+	w.ResponseWriter.WriteHeader(w.status)
+	if len(w.buf) > 0 {
+		_, _ = w.ResponseWriter.Write(w.buf)
+		w.buf = nil
+	}
+}
+
+// typeAllowed 判断媒体类型是否在允许压缩的列表中，以 "/" 结尾的条目按前缀匹配
+func typeAllowed(mediaType string, allowed []string) bool {
+	for _, t := range allowed {
+		t = strings.ToLower(t)
+		if t == mediaType || (strings.HasSuffix(t, "/") && strings.HasPrefix(mediaType, t)) {
+			return true
+		}
+	}
+	return false
+}
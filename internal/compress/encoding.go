@@ -0,0 +1,167 @@
+package compress
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/Xushengqwer/gateway/internal/config"
+
+	"github.com/andybalholm/brotli"
+	"github.com/klauspost/compress/gzip"
+	"github.com/klauspost/compress/zstd"
+)
+
+// defaultBrotliLevel 是未配置 level 时的 brotli 级别，兼顾实时压缩的速度与压缩率
+const defaultBrotliLevel = 4
+
+// encoder 是可复用的流式压缩器
+type encoder interface {
+	io.WriteCloser
+	Flush() error
+	Reset(w io.Writer)
+}
+
+// encoderPools 按 "算法/级别" 缓存压缩器，避免每个响应重新分配（zstd、brotli 的初始化开销较大）
+var encoderPools sync.Map
+
+// acquireEncoder 从对象池获取绑定到 w 的压缩器
+func acquireEncoder(encoding string, level int, w io.Writer) encoder {
+	key := encoding + "/" + strconv.Itoa(level)
+	pool, ok := encoderPools.Load(key)
+	if !ok {
+		pool, _ = encoderPools.LoadOrStore(key, &sync.Pool{New: func() any { return newEncoder(encoding, level) }})
+	}
+	enc := pool.(*sync.Pool).Get().(encoder)
+	enc.Reset(w)
+	return enc
+}
+
+// releaseEncoder 把已 Close 的压缩器放回对象池
+func releaseEncoder(encoding string, level int, enc encoder) {
+	enc.Reset(io.Discard)
+	if pool, ok := encoderPools.Load(encoding + "/" + strconv.Itoa(level)); ok {
+		pool.(*sync.Pool).Put(enc)
+	}
+}
+
+// newEncoder 创建压缩器，level 为 1-9（0 表示算法默认级别），按比例映射到各算法的级别范围
+func newEncoder(encoding string, level int) encoder {
+	switch encoding {
+	case config.EncodingBrotli:
+		brLevel := defaultBrotliLevel
+		if level > 0 {
+			brLevel = level * brotli.BestCompression / 9
+		}
+		return brotli.NewWriterLevel(io.Discard, brLevel)
+	case config.EncodingZstd:
+		zLevel := zstd.SpeedDefault
+		if level > 0 {
+			zLevel = zstd.EncoderLevelFromZstd(level)
+		}
+		enc, _ := zstd.NewWriter(io.Discard, zstd.WithEncoderLevel(zLevel), zstd.WithEncoderConcurrency(1))
+		return enc
+	default:
+		gzLevel := gzip.DefaultCompression
+		if level > 0 {
+			gzLevel = level
+		}
+		enc, _ := gzip.NewWriterLevel(io.Discard, gzLevel)
+		return enc
+	}
+}
+
+// Supported 判断是否为网关支持的压缩算法
+func Supported(encoding string) bool {
+	switch encoding {
+	case config.EncodingBrotli, config.EncodingZstd, config.EncodingGzip:
+		return true
+	}
+	return false
+}
+
+// Negotiate 按客户端 Accept-Encoding 与服务端偏好顺序选择压缩算法，无可用算法时返回 ""
+// - q 值高者优先，q 值相同时取 preferred 中靠前的算法；q=0 表示拒绝；"*" 匹配未显式列出的算法
+func Negotiate(acceptEncoding string, preferred []string) string {
+	if acceptEncoding == "" {
+		return ""
+	}
+	weights := make(map[string]float64)
+	wildcard := -1.0
+	for _, part := range strings.Split(acceptEncoding, ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		q := 1.0
+		if k, v, ok := strings.Cut(strings.TrimSpace(params), "="); ok && strings.TrimSpace(k) == "q" {
+			if parsed, err := strconv.ParseFloat(strings.TrimSpace(v), 64); err == nil {
+				q = parsed
+			}
+		}
+		if name == "*" {
+			wildcard = q
+			continue
+		}
+		weights[name] = q
+	}
+
+	best, bestQ := "", 0.0
+	for _, enc := range preferred {
+		q, ok := weights[enc]
+		if !ok {
+			q = wildcard
+		}
+		if q > bestQ {
+			best, bestQ = enc, q
+		}
+	}
+	return best
+}
+
+// 请求体解压错误
+var (
+	ErrUnsupportedEncoding = errors.New("不支持的请求体编码")
+	ErrInvalidBody         = errors.New("请求体无法按声明的编码解压")
+)
+
+// newDecoder 为压缩的请求体创建解压读取器，关闭时同时关闭原始请求体
+func newDecoder(encoding string, body io.ReadCloser, maxBytes int64) (io.ReadCloser, error) {
+	switch encoding {
+	case config.EncodingGzip, "x-gzip":
+		zr, err := gzip.NewReader(body)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidBody, err)
+		}
+		return &decoder{Reader: zr, close: zr.Close, body: body}, nil
+	case config.EncodingBrotli:
+		return &decoder{Reader: brotli.NewReader(body), body: body}, nil
+	case config.EncodingZstd:
+		zr, err := zstd.NewReader(body, zstd.WithDecoderConcurrency(1), zstd.WithDecoderMaxMemory(uint64(maxBytes)))
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidBody, err)
+		}
+		return &decoder{Reader: zr, close: func() error { zr.Close(); return nil }, body: body}, nil
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedEncoding, encoding)
+	}
+}
+
+// decoder 组合解压读取器与原始请求体
+type decoder struct {
+	io.Reader
+	close func() error
+	body  io.ReadCloser
+}
+
+// Close 关闭解压器与原始请求体
+func (d *decoder) Close() error {
+	if d.close != nil {
+		_ = d.close()
+	}
+	return d.body.Close()
+}
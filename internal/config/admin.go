@@ -0,0 +1,25 @@
+package config
+
+// AdminConfig 定义网关管理 API 的配置
+// - 管理 API 与业务路由共用监听端口，通过独立前缀和令牌保护
+// 例如：
+//
+//	Enabled: true
+//	Prefix: /_gateway/admin
+//	Token: 由环境变量 ADMIN_TOKEN 注入
+type AdminConfig struct {
+	Enabled bool   `mapstructure:"enabled" json:"enabled" yaml:"enabled"` // 是否启用管理 API
+	Prefix  string `mapstructure:"prefix" json:"prefix" yaml:"prefix"`    // 管理 API 路径前缀，默认 /_gateway/admin
+	Token   string `mapstructure:"token" json:"-" yaml:"token"`           // 访问令牌，请求需携带 "Authorization: Bearer <token>"
+}
+
+// DefaultAdminPrefix 是未配置 Prefix 时管理 API 使用的路径前缀
+const DefaultAdminPrefix = "/_gateway/admin"
+
+// PathPrefix 返回规范化后的管理 API 前缀
+func (ac AdminConfig) PathPrefix() string {
+	if prefix := NormalizePrefix(ac.Prefix); prefix != "" {
+		return prefix
+	}
+	return DefaultAdminPrefix
+}
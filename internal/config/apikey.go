@@ -0,0 +1,62 @@
+package config
+
+import (
+	"fmt"
+	"time"
+)
+
+// DefaultAPIKeyHeader 是未配置 header 时读取 API Key 的请求头
+const DefaultAPIKeyHeader = "X-API-Key"
+
+// APIKeyConfig 定义 API Key 认证的配置
+// - Key 只以哈希形式保存在 storeFile（JSON）中，由管理 API 或 `gateway apikey` 子命令维护
+// - 网关运行时会自动加载 storeFile 的变更，CLI 修改后无需重启
+// 例如：
+//
+//	storeFile: "/var/lib/gateway/apikeys.json"
+//	header: "X-API-Key"
+//	queryParam: "api_key" // 为空则不接受查询参数中的 Key
+//	plans:
+//	  partner-basic: {capacity: 60, refillInterval: 1s}
+type APIKeyConfig struct {
+	StoreFile  string                `mapstructure:"storeFile" json:"storeFile" yaml:"storeFile"`
+	Header     string                `mapstructure:"header" json:"header" yaml:"header"`
+	QueryParam string                `mapstructure:"queryParam" json:"queryParam" yaml:"queryParam"`
+	Plans      map[string]APIKeyPlan `mapstructure:"plans" json:"plans" yaml:"plans"` // 限流套餐，Key 通过 plan 引用
+}
+
+// APIKeyPlan 是按 Key 计算的令牌桶限流套餐，语义同 RateLimitConfig
+type APIKeyPlan struct {
+	Capacity       int           `mapstructure:"capacity" json:"capacity" yaml:"capacity"`
+	RefillInterval time.Duration `mapstructure:"refillInterval" json:"refillInterval" yaml:"refillInterval"`
+}
+
+// HeaderName 返回读取 API Key 的请求头名称
+func (ac *APIKeyConfig) HeaderName() string {
+	if ac.Header != "" {
+		return ac.Header
+	}
+	return DefaultAPIKeyHeader
+}
+
+// CheckAPIKeyGrant 校验创建 API Key 时引用的套餐与服务是否存在于当前配置
+func (gc *GatewayConfig) CheckAPIKeyGrant(plan string, services []string) error {
+	if plan != "" {
+		if gc.APIKeys == nil {
+			return fmt.Errorf("套餐 %q 未配置", plan)
+		}
+		if _, ok := gc.APIKeys.Plans[plan]; !ok {
+			return fmt.Errorf("套餐 %q 未配置", plan)
+		}
+	}
+	names := make(map[string]bool, len(gc.Services))
+	for _, svc := range gc.Services {
+		names[svc.Name] = true
+	}
+	for _, name := range services {
+		if !names[name] {
+			return fmt.Errorf("服务 %q 不存在", name)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,48 @@
+package config
+
+// AuditConfig 定义授权审计日志配置
+// - 每个认证与权限判断都会产生一条 JSON 审计记录，与请求日志分开写入
+// - 拒绝记录总是写入；放行记录按 SampleAllowed 采样
+// 例如：
+//
+//	Enabled: true
+//	File: /var/log/gateway/audit.log
+//	MaxSizeMB: 100
+//	MaxBackups: 10
+//	SampleAllowed: 0.1
+type AuditConfig struct {
+	Enabled       bool     `mapstructure:"enabled" json:"enabled" yaml:"enabled"`                   // 是否启用审计日志
+	File          string   `mapstructure:"file" json:"file" yaml:"file"`                            // 审计文件路径，"stdout" / "stderr" 表示写入标准输出 / 标准错误
+	MaxSizeMB     int      `mapstructure:"maxSizeMB" json:"maxSizeMB" yaml:"maxSizeMB"`             // 单个文件的最大大小 (MB)，超过后轮转，默认 100
+	MaxBackups    int      `mapstructure:"maxBackups" json:"maxBackups" yaml:"maxBackups"`          // 保留的历史文件数，默认 10
+	SampleAllowed *float64 `mapstructure:"sampleAllowed" json:"sampleAllowed" yaml:"sampleAllowed"` // 放行记录的采样比例 [0,1]，默认 1（全部记录）
+}
+
+const (
+	defaultAuditMaxSizeMB  = 100
+	defaultAuditMaxBackups = 10
+)
+
+// MaxSizeBytes 返回轮转阈值（字节）
+func (a *AuditConfig) MaxSizeBytes() int64 {
+	if a.MaxSizeMB <= 0 {
+		return defaultAuditMaxSizeMB << 20
+	}
+	return int64(a.MaxSizeMB) << 20
+}
+
+// Backups 返回保留的历史文件数
+func (a *AuditConfig) Backups() int {
+	if a.MaxBackups <= 0 {
+		return defaultAuditMaxBackups
+	}
+	return a.MaxBackups
+}
+
+// AllowedSampleRate 返回放行记录的采样比例
+func (a *AuditConfig) AllowedSampleRate() float64 {
+	if a.SampleAllowed == nil {
+		return 1
+	}
+	return *a.SampleAllowed
+}
@@ -0,0 +1,143 @@
+package config
+
+import (
+	"time"
+
+	"github.com/Xushengqwer/go-common/models/enums"
+)
+
+// 路由可用的认证方式
+const (
+	AuthSchemeJWT    = "jwt"    // Authorization: Bearer <access token>
+	AuthSchemeMTLS   = "mtls"   // 经 HTTPS 监听校验过的客户端证书
+	AuthSchemeAPIKey = "apikey" // apiKeys.header 请求头或 apiKeys.queryParam 查询参数中的 API Key
+	AuthSchemeHMAC   = "hmac"   // Authorization: GW-HMAC-SHA256 请求签名，密钥取自 hmacAuth.keys
+	AuthSchemeBasic  = "basic"  // Authorization: Basic，账号取自 basicAuth.users
+	AuthSchemeNone   = "none"   // 不要求凭证，以访客角色继续；放在列表末尾表示凭证可选
+)
+
+// DefaultAuthSchemes 是路由未配置 auth 时使用的认证方式
+var DefaultAuthSchemes = []string{AuthSchemeJWT}
+
+// AuthSchemes 返回路由接受的认证方式，按配置顺序依次尝试：路由 > 服务 > DefaultAuthSchemes
+func (s *ServiceConfig) AuthSchemes(route *RouteConfig) []string {
+	if route != nil && len(route.Auth) > 0 {
+		return route.Auth
+	}
+	if len(s.Auth) > 0 {
+		return s.Auth
+	}
+	return DefaultAuthSchemes
+}
+
+// ClientCertAuthConfig 定义客户端证书到网关身份的映射
+// - 只有经 tls.clientCAFile 校验通过的证书才会参与映射；按顺序取第一条匹配的身份
+// 例如：
+//
+//	identities:
+//	  - {commonName: "batch-job", userID: "svc-batch-job", role: 0}
+//	  - {san: "spiffe://cluster/ns/jobs/sa/report", userID: "svc-report", role: 1}
+type ClientCertAuthConfig struct {
+	Identities []ClientCertIdentity `mapstructure:"identities" json:"identities" yaml:"identities"`
+}
+
+// ClientCertIdentity 是一条证书身份映射规则，配置的条件需全部满足
+type ClientCertIdentity struct {
+	CommonName string         `mapstructure:"commonName" json:"commonName,omitempty" yaml:"commonName,omitempty"` // 证书主题 CN，精确匹配
+	SAN        string         `mapstructure:"san" json:"san,omitempty" yaml:"san,omitempty"`                      // 任一 DNS / URI / Email SAN 精确匹配
+	UserID     string         `mapstructure:"userID" json:"userID" yaml:"userID"`                                 // 映射后的用户 ID，透传给上游的 X-User-ID
+	Role       enums.UserRole `mapstructure:"role" json:"role" yaml:"role"`                                       // 映射后的角色，参与 allowedRoles 判断
+}
+
+// BasicAuthConfig 定义 HTTP Basic 认证的账号，供内部工具等无法使用 JWT 的调用方使用
+// - 密码只以 bcrypt 哈希保存，可用 `htpasswd -nbB <user> <password>` 生成
+// 例如：
+//
+//	users:
+//	  - {username: "grafana", passwordHash: "$2y$10$...", role: 1}
+type BasicAuthConfig struct {
+	Users []BasicAuthUser `mapstructure:"users" json:"users" yaml:"users"`
+}
+
+// BasicAuthUser 是一个 Basic 认证账号
+type BasicAuthUser struct {
+	Username     string         `mapstructure:"username" json:"username" yaml:"username"`
+	PasswordHash string         `mapstructure:"passwordHash" json:"-" yaml:"passwordHash"`
+	UserID       string         `mapstructure:"userID" json:"userID,omitempty" yaml:"userID,omitempty"` // 透传给上游的用户 ID，默认取 username
+	Role         enums.UserRole `mapstructure:"role" json:"role" yaml:"role"`
+}
+
+// HMACAuthConfig 定义服务间调用 / Webhook 使用的 HMAC 请求签名认证
+// - 调用方用 keyID 对应的共享密钥对方法、路径、查询参数、选定请求头、时间戳、nonce 与请求体哈希签名
+// - 时间戳与网关时间相差超过 maxSkew 的请求被拒绝；maxSkew 内重复出现的 nonce 视为重放
+// - nonce 记录保存在网关实例内存中，多副本部署时只能防止同一实例上的重放
+// 例如：
+//
+//	maxSkew: 5m
+//	keys:
+//	  - {keyID: "payments-webhook", secret: "由环境变量注入", userID: "svc-payments", role: 1}
+type HMACAuthConfig struct {
+	Keys         []HMACKey     `mapstructure:"keys" json:"keys" yaml:"keys"`
+	MaxSkew      time.Duration `mapstructure:"maxSkew" json:"maxSkew" yaml:"maxSkew"`                // 允许的时钟偏差，默认 5m
+	MaxBodyBytes int64         `mapstructure:"maxBodyBytes" json:"maxBodyBytes" yaml:"maxBodyBytes"` // 计算签名时可读取的最大请求体，默认 10MiB；服务或路由合并后的 maxBodyBytes 更小时以其为准
+}
+
+// HMACKey 是一个签名密钥及其映射的身份
+type HMACKey struct {
+	KeyID  string         `mapstructure:"keyID" json:"keyID" yaml:"keyID"`
+	Secret string         `mapstructure:"secret" json:"-" yaml:"secret"`
+	UserID string         `mapstructure:"userID" json:"userID,omitempty" yaml:"userID,omitempty"` // 透传给上游的用户 ID，默认取 keyID
+	Role   enums.UserRole `mapstructure:"role" json:"role" yaml:"role"`
+	Scopes []string       `mapstructure:"scopes" json:"scopes,omitempty" yaml:"scopes,omitempty"`
+}
+
+// 授权范围与路由角色要求（allowedRoles / roles / minRole）的组合方式
+const (
+	ScopeModeAny = "any" // 默认：角色匹配或授权范围满足其一即可
+	ScopeModeAll = "all" // 角色与授权范围都要满足
+)
+
+// ScopeRequirement 定义路由要求的授权范围（OAuth scope / 细粒度权限，如 "post:audit"）
+//   - AllOf 中的范围必须全部具备（AND），AnyOf 中至少具备一个（OR），两者同时配置时都要满足
+//   - 路由同时配置了角色要求时按 Mode 组合：默认 any 为满足其一即可，便于给普通用户单独授予 post:audit；
+//     all 要求角色与授权范围都满足，如版主还必须持有 post:audit
+type ScopeRequirement struct {
+	AllOf []string `yaml:"allOf,omitempty"`
+	AnyOf []string `yaml:"anyOf,omitempty"`
+	Mode  string   `yaml:"mode,omitempty"` // 与角色要求的组合方式：any（默认）/ all
+}
+
+// Empty 判断是否未要求任何授权范围
+func (r *ScopeRequirement) Empty() bool {
+	return r == nil || (len(r.AllOf) == 0 && len(r.AnyOf) == 0)
+}
+
+// RequiresRole 判断授权范围是否需要与角色要求同时满足（mode: all）
+func (r *ScopeRequirement) RequiresRole() bool {
+	return !r.Empty() && r.Mode == ScopeModeAll
+}
+
+// SatisfiedBy 判断给定的授权范围是否满足要求
+func (r *ScopeRequirement) SatisfiedBy(scopes []string) bool {
+	if r.Empty() {
+		return false
+	}
+	granted := make(map[string]bool, len(scopes))
+	for _, s := range scopes {
+		granted[s] = true
+	}
+	for _, s := range r.AllOf {
+		if !granted[s] {
+			return false
+		}
+	}
+	if len(r.AnyOf) == 0 {
+		return true
+	}
+	for _, s := range r.AnyOf {
+		if granted[s] {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,25 @@
+package config
+
+import "time"
+
+// ResponseCacheConfig 定义网关响应缓存的全局配置（内存 LRU）
+// 例如：
+//
+//	maxBytes: 67108864     // 缓存总容量 64MiB
+//	maxEntryBytes: 1048576 // 单条响应最大 1MiB，超过则不缓存
+//	defaultTTL: 0s         // 上游未声明 max-age 且路由未覆盖 TTL 时不缓存
+type ResponseCacheConfig struct {
+	MaxBytes      int64         `mapstructure:"maxBytes" json:"maxBytes" yaml:"maxBytes"`
+	MaxEntryBytes int64         `mapstructure:"maxEntryBytes" json:"maxEntryBytes" yaml:"maxEntryBytes"`
+	DefaultTTL    time.Duration `mapstructure:"defaultTTL" json:"defaultTTL" yaml:"defaultTTL"`
+}
+
+// CacheRule 定义服务下某个公开 GET 路径的缓存策略
+// - 只对公开路径、GET/HEAD 且不带 Authorization 头的请求生效
+// - 上游的 Cache-Control: no-store/private/no-cache 与 Set-Cookie 始终被尊重，不会被 TTL 覆盖
+type CacheRule struct {
+	Path                 string        `yaml:"path"`                           // 路径模式（相对服务前缀，语法同 publicPaths）
+	TTL                  time.Duration `yaml:"ttl,omitempty"`                  // 覆盖上游 max-age 的新鲜期
+	StaleWhileRevalidate time.Duration `yaml:"staleWhileRevalidate,omitempty"` // 过期后仍可直接返回旧响应并在后台刷新的时长
+	KeyHeaders           []string      `yaml:"keyHeaders,omitempty"`           // 参与缓存键的请求头，如 X-Platform
+}
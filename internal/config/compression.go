@@ -0,0 +1,107 @@
+package config
+
+// 支持的压缩算法名称（与 Content-Encoding 取值一致）
+const (
+	EncodingBrotli = "br"
+	EncodingZstd   = "zstd"
+	EncodingGzip   = "gzip"
+)
+
+// DefaultCompressionAlgorithms 是未配置 algorithms 时的服务端偏好顺序
+var DefaultCompressionAlgorithms = []string{EncodingBrotli, EncodingZstd, EncodingGzip}
+
+// DefaultCompressibleTypes 是未配置 contentTypes 时允许压缩的响应类型
+// - 以 "/" 结尾的条目按前缀匹配，如 "text/" 匹配所有文本类型
+var DefaultCompressibleTypes = []string{
+	"application/json",
+	"application/problem+json",
+	"application/javascript",
+	"application/xml",
+	"image/svg+xml",
+	"text/",
+}
+
+const (
+	defaultCompressionMinSize       = 1024    // 小于 1KiB 的响应压缩收益很小
+	defaultMaxDecompressedBodyBytes = 8 << 20 // 解压后的请求体默认上限 8MiB
+)
+
+// CompressionConfig 定义网关的响应压缩与请求体解压配置
+// - 全局配置作为默认值，服务级配置中非零的字段覆盖全局值
+// - 已带 Content-Encoding 的响应、text/event-stream 流式响应和不在 contentTypes 内的响应不压缩
+// 例如：
+//
+//	enabled: true
+//	algorithms: ["br", "zstd", "gzip"] // 客户端 q 值相同时按此顺序选择
+//	minSize: 1024
+//	contentTypes: ["application/json", "text/"]
+//	decompressRequests: true           // 接受 Content-Encoding: gzip/br/zstd 的请求体并解压后转发
+//	maxDecompressedBytes: 8388608
+type CompressionConfig struct {
+	Enabled              *bool    `mapstructure:"enabled" json:"enabled,omitempty" yaml:"enabled,omitempty"`
+	Algorithms           []string `mapstructure:"algorithms" json:"algorithms,omitempty" yaml:"algorithms,omitempty"`
+	Level                int      `mapstructure:"level" json:"level,omitempty" yaml:"level,omitempty"` // 压缩级别（1-9，映射到各算法），0 表示各算法默认级别
+	MinSize              int      `mapstructure:"minSize" json:"minSize,omitempty" yaml:"minSize,omitempty"`
+	ContentTypes         []string `mapstructure:"contentTypes" json:"contentTypes,omitempty" yaml:"contentTypes,omitempty"`
+	DecompressRequests   *bool    `mapstructure:"decompressRequests" json:"decompressRequests,omitempty" yaml:"decompressRequests,omitempty"`
+	MaxDecompressedBytes int64    `mapstructure:"maxDecompressedBytes" json:"maxDecompressedBytes,omitempty" yaml:"maxDecompressedBytes,omitempty"`
+}
+
+// Merge 以 gc 为默认值合并服务级覆盖配置，并填充默认值，返回新的配置
+// - gc 与 override 均可为 nil；两者都为 nil 时返回 nil，表示不启用
+func (gc *CompressionConfig) Merge(override *CompressionConfig) *CompressionConfig {
+	if gc == nil && override == nil {
+		return nil
+	}
+	merged := CompressionConfig{}
+	for _, c := range []*CompressionConfig{gc, override} {
+		if c == nil {
+			continue
+		}
+		if c.Enabled != nil {
+			merged.Enabled = c.Enabled
+		}
+		if len(c.Algorithms) > 0 {
+			merged.Algorithms = c.Algorithms
+		}
+		if c.Level != 0 {
+			merged.Level = c.Level
+		}
+		if c.MinSize > 0 {
+			merged.MinSize = c.MinSize
+		}
+		if len(c.ContentTypes) > 0 {
+			merged.ContentTypes = c.ContentTypes
+		}
+		if c.DecompressRequests != nil {
+			merged.DecompressRequests = c.DecompressRequests
+		}
+		if c.MaxDecompressedBytes > 0 {
+			merged.MaxDecompressedBytes = c.MaxDecompressedBytes
+		}
+	}
+
+	if len(merged.Algorithms) == 0 {
+		merged.Algorithms = DefaultCompressionAlgorithms
+	}
+	if merged.MinSize == 0 {
+		merged.MinSize = defaultCompressionMinSize
+	}
+	if len(merged.ContentTypes) == 0 {
+		merged.ContentTypes = DefaultCompressibleTypes
+	}
+	if merged.MaxDecompressedBytes == 0 {
+		merged.MaxDecompressedBytes = defaultMaxDecompressedBodyBytes
+	}
+	return &merged
+}
+
+// CompressEnabled 返回是否启用响应压缩（未显式配置 enabled 时视为启用）
+func (gc *CompressionConfig) CompressEnabled() bool {
+	return gc != nil && (gc.Enabled == nil || *gc.Enabled)
+}
+
+// DecompressEnabled 返回是否解压客户端上传的压缩请求体
+func (gc *CompressionConfig) DecompressEnabled() bool {
+	return gc != nil && gc.DecompressRequests != nil && *gc.DecompressRequests
+}
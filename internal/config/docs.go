@@ -0,0 +1,70 @@
+package config
+
+import "time"
+
+// DocsConfig 定义聚合 API 文档
+// - 网关加载各服务 openapi 配置的文档，把路径改写为网关路径并按访问策略标注认证要求，合并为一份文档
+// - {path} 提供 Swagger UI，{path}/openapi.json 提供合并后的文档
+// 例如：
+//
+//	docs:
+//	  enabled: true
+//	  path: /docs
+//	  title: "社区平台 API"
+//	  refreshInterval: 5m
+type DocsConfig struct {
+	Enabled         bool          `mapstructure:"enabled" json:"enabled" yaml:"enabled"`
+	Path            string        `mapstructure:"path" json:"path" yaml:"path"`                                  // 文档路径，默认 /docs
+	Title           string        `mapstructure:"title" json:"title" yaml:"title"`                               // 合并文档的标题，默认 "API Gateway"
+	Version         string        `mapstructure:"version" json:"version" yaml:"version"`                         // 合并文档的版本，默认 "1.0"
+	RefreshInterval time.Duration `mapstructure:"refreshInterval" json:"refreshInterval" yaml:"refreshInterval"` // 重新加载各服务文档的间隔，默认 5m
+	FetchTimeout    time.Duration `mapstructure:"fetchTimeout" json:"fetchTimeout" yaml:"fetchTimeout"`          // 从上游拉取单个文档的超时，默认 5s
+	SwaggerUIURL    string        `mapstructure:"swaggerUIURL" json:"swaggerUIURL" yaml:"swaggerUIURL"`          // swagger-ui-dist 静态资源地址，默认使用公共 CDN
+}
+
+// DefaultDocsPath 是未配置 path 时聚合文档使用的路径
+const DefaultDocsPath = "/docs"
+
+// DefaultSwaggerUIURL 是未配置 swaggerUIURL 时 Swagger UI 加载静态资源的地址
+const DefaultSwaggerUIURL = "https://cdn.jsdelivr.net/npm/swagger-ui-dist@5"
+
+// PathPrefix 返回规范化后的文档路径
+func (dc *DocsConfig) PathPrefix() string {
+	if prefix := NormalizePrefix(dc.Path); prefix != "" {
+		return prefix
+	}
+	return DefaultDocsPath
+}
+
+// Refresh 返回重新加载文档的间隔
+func (dc *DocsConfig) Refresh() time.Duration {
+	if dc.RefreshInterval > 0 {
+		return dc.RefreshInterval
+	}
+	return 5 * time.Minute
+}
+
+// Timeout 返回拉取单个文档的超时
+func (dc *DocsConfig) Timeout() time.Duration {
+	if dc.FetchTimeout > 0 {
+		return dc.FetchTimeout
+	}
+	return 5 * time.Second
+}
+
+// UIAssets 返回 Swagger UI 静态资源地址
+func (dc *DocsConfig) UIAssets() string {
+	if dc.SwaggerUIURL != "" {
+		return dc.SwaggerUIURL
+	}
+	return DefaultSwaggerUIURL
+}
+
+// OpenAPISource 定义服务 OpenAPI / Swagger 文档的来源，file 与 url 二选一
+// - url 以 "/" 开头时相对服务上游地址，如 "/swagger/doc.json"
+// - basePath 覆盖文档中的 basePath（Swagger 2.0）或 servers 路径（OpenAPI 3），即文档路径在上游的挂载点
+type OpenAPISource struct {
+	File     string `yaml:"file,omitempty"`
+	URL      string `yaml:"url,omitempty"`
+	BasePath string `yaml:"basePath,omitempty"`
+}
@@ -15,4 +15,19 @@ type GatewayConfig struct {
 	RateLimitConfig *RateLimitConfig `mapstructure:"rateLimitConfig" json:"rateLimitConfig" yaml:"rateLimitConfig"` // 速率限制配置
 	Services        []ServiceConfig  `mapstructure:"services" json:"services" yaml:"services"`                      // 下游服务配置列表
 	Cors            CorsConfig       `mapstructure:"cors" yaml:"cors"`                                              // **新增 CORS 配置段**
+	Admin           AdminConfig      `mapstructure:"admin" json:"admin" yaml:"admin"`                               // 管理 API 配置
+	Limits          LimitsConfig     `mapstructure:"limits" json:"limits" yaml:"limits"`                            // 请求大小限制
+
+	ResponseCache *ResponseCacheConfig  `mapstructure:"responseCache" json:"responseCache" yaml:"responseCache"`    // 响应缓存配置（为空则不启用）
+	Compression   *CompressionConfig    `mapstructure:"compression" json:"compression" yaml:"compression"`          // 响应压缩默认配置（服务可覆盖）
+	TLS           *TLSConfig            `mapstructure:"tls" json:"tls" yaml:"tls"`                                  // HTTPS 监听配置（为空或未启用则只监听 HTTP）
+	ClientCert    *ClientCertAuthConfig `mapstructure:"clientCertAuth" json:"clientCertAuth" yaml:"clientCertAuth"` // 客户端证书身份映射（auth: ["mtls"] 的路由使用）
+	APIKeys       *APIKeyConfig         `mapstructure:"apiKeys" json:"apiKeys" yaml:"apiKeys"`                      // API Key 认证配置（auth: ["apikey"] 的路由使用）
+	BasicAuth     *BasicAuthConfig      `mapstructure:"basicAuth" json:"basicAuth" yaml:"basicAuth"`                // Basic 认证账号（auth: ["basic"] 的路由使用）
+	HMACAuth      *HMACAuthConfig       `mapstructure:"hmacAuth" json:"hmacAuth" yaml:"hmacAuth"`                   // HMAC 请求签名密钥（auth: ["hmac"] 的路由使用）
+	Roles         []RoleConfig          `mapstructure:"roles" json:"roles" yaml:"roles"`                            // 具名角色与继承关系（为空时使用 DefaultRoles）
+	Audit         *AuditConfig          `mapstructure:"audit" json:"audit" yaml:"audit"`                            // 授权审计日志（为空或未启用则不记录）
+	Docs          *DocsConfig           `mapstructure:"docs" json:"docs" yaml:"docs"`                               // 聚合 API 文档（为空或未启用则不提供）
+
+	ExposeRouteOnError bool `mapstructure:"exposeRouteOnError" json:"exposeRouteOnError" yaml:"exposeRouteOnError"` // 网关拒绝请求时在 X-Gateway-Route 响应头中返回命中路由的名称，便于调用方定位
 }
@@ -0,0 +1,42 @@
+package config
+
+// LimitsConfig 定义请求大小的全局限制
+// 例如：
+//
+//	maxBodyBytes: 10485760 // 请求体默认上限 10MiB，服务与路由可覆盖
+//	maxHeaderBytes: 16384  // 请求头总大小上限（请求行 + 所有头部）
+//	maxHeaderCount: 100    // 请求头字段数上限
+type LimitsConfig struct {
+	MaxBodyBytes   int64 `mapstructure:"maxBodyBytes" json:"maxBodyBytes" yaml:"maxBodyBytes"`
+	MaxHeaderBytes int   `mapstructure:"maxHeaderBytes" json:"maxHeaderBytes" yaml:"maxHeaderBytes"`
+	MaxHeaderCount int   `mapstructure:"maxHeaderCount" json:"maxHeaderCount" yaml:"maxHeaderCount"`
+}
+
+// BodyLimit 是服务或路由级的请求体限制
+// - 路由级的非零字段覆盖服务级，服务级覆盖全局 limits.maxBodyBytes
+// - 内容类型支持 "image/*" 形式的通配；allowedPartTypes 只检查 multipart 请求中带文件名的部分
+type BodyLimit struct {
+	MaxBodyBytes        int64    `yaml:"maxBodyBytes,omitempty"`        // 请求体最大字节数，0 表示继承
+	AllowedContentTypes []string `yaml:"allowedContentTypes,omitempty"` // 允许的请求 Content-Type，为空表示不限制
+	AllowedPartTypes    []string `yaml:"allowedPartTypes,omitempty"`    // multipart 文件部分允许的 Content-Type，如 ["image/*"]
+}
+
+// MergeBodyLimits 按 全局 -> 服务 -> 路由 的顺序合并请求体限制，后者的非零字段覆盖前者
+func MergeBodyLimits(globalMax int64, layers ...*BodyLimit) BodyLimit {
+	merged := BodyLimit{MaxBodyBytes: globalMax}
+	for _, l := range layers {
+		if l == nil {
+			continue
+		}
+		if l.MaxBodyBytes > 0 {
+			merged.MaxBodyBytes = l.MaxBodyBytes
+		}
+		if len(l.AllowedContentTypes) > 0 {
+			merged.AllowedContentTypes = l.AllowedContentTypes
+		}
+		if len(l.AllowedPartTypes) > 0 {
+			merged.AllowedPartTypes = l.AllowedPartTypes
+		}
+	}
+	return merged
+}
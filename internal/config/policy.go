@@ -0,0 +1,43 @@
+package config
+
+// 服务默认策略：请求既不匹配 publicPaths 也不匹配 routes 时如何处理
+const (
+	DefaultPolicyDeny          = "deny"          // 返回 404（默认），必须逐一列出上游接口
+	DefaultPolicyAuthenticated = "authenticated" // 任意已认证（非匿名、未拉黑）的身份均可访问
+	DefaultPolicyPublic        = "public"        // 无需认证直接转发
+)
+
+// MethodWildcard 写在 methods 中表示匹配所有 HTTP 方法，与不配置 methods 等价
+// - 多条规则同样具体时，显式列出方法的规则优先于方法通配
+const MethodWildcard = "*"
+
+// DenyRule 定义显式拒绝规则，优先于 publicPaths、routes 与 defaultPolicy
+// - Path 语法同 routes（见 pathpattern 包），如 "/admin/**"
+// - 设置 ExceptRoles 时，具备其中任一角色（含继承）的身份不受限制，命中该规则的请求因此必须先认证
+type DenyRule struct {
+	Path        string   `yaml:"path"`                  // 路径模式
+	Methods     []string `yaml:"methods,omitempty"`     // 适用的 HTTP 方法，为空或含 "*" 则匹配所有方法
+	ExceptRoles []string `yaml:"exceptRoles,omitempty"` // 豁免的具名角色（可选）
+	Reason      string   `yaml:"reason,omitempty"`      // 拒绝原因，记录在日志中（可选）
+	Enforce     *bool    `yaml:"enforce,omitempty"`     // false 表示试运行：只记录本应拒绝的请求，仍然放行（覆盖服务级 enforce）
+}
+
+// EffectiveDefaultPolicy 返回服务的默认策略，未配置时为 deny
+func (s *ServiceConfig) EffectiveDefaultPolicy() string {
+	if s.DefaultPolicy == "" {
+		return DefaultPolicyDeny
+	}
+	return s.DefaultPolicy
+}
+
+// Enforced 判断规则是否强制执行：规则自身的 enforce 优先，其次服务级 enforce，默认强制执行
+// - 未强制执行（试运行）的规则仍参与判断，本应拒绝的请求只记录日志与计数，不会被拦截
+func (s *ServiceConfig) Enforced(rule *bool) bool {
+	if rule != nil {
+		return *rule
+	}
+	if s.Enforce != nil {
+		return *s.Enforce
+	}
+	return true
+}
@@ -0,0 +1,175 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/Xushengqwer/go-common/models/enums"
+)
+
+// RoleConfig 定义一个具名角色及其继承关系
+// - Value 是令牌 / API Key 等身份中携带的 enums.UserRole 数值
+// - Inherits 中的角色能访问的路由，本角色也能访问（可传递）
+// 例如：
+//
+//	roles:
+//	  - {name: guest, value: 2}
+//	  - {name: user, value: 1, inherits: [guest]}
+//	  - {name: moderator, value: 3, inherits: [user]}
+//	  - {name: admin, value: 0, inherits: [moderator]}
+type RoleConfig struct {
+	Name     string         `mapstructure:"name" json:"name" yaml:"name"`
+	Value    enums.UserRole `mapstructure:"value" json:"value" yaml:"value"`
+	Inherits []string       `mapstructure:"inherits" json:"inherits,omitempty" yaml:"inherits,omitempty"`
+}
+
+// DefaultRoles 是未配置 roles 时使用的角色定义：admin ⊇ user ⊇ guest
+var DefaultRoles = []RoleConfig{
+	{Name: "guest", Value: enums.RoleGuest},
+	{Name: "user", Value: enums.RoleUser, Inherits: []string{"guest"}},
+	{Name: "admin", Value: enums.RoleAdmin, Inherits: []string{"user"}},
+}
+
+// RoleHierarchy 是展开继承关系后的角色表
+type RoleHierarchy struct {
+	byName  map[string]RoleConfig
+	byValue map[enums.UserRole]string
+	// grants[value] 是该角色值（含继承）具备的全部角色名
+	grants map[enums.UserRole]map[string]bool
+}
+
+// NewRoleHierarchy 校验角色定义并展开继承关系
+// - 名称与数值都必须唯一；继承的角色必须存在且不能成环
+func NewRoleHierarchy(defs []RoleConfig) (*RoleHierarchy, error) {
+	h := &RoleHierarchy{
+		byName:  make(map[string]RoleConfig, len(defs)),
+		byValue: make(map[enums.UserRole]string, len(defs)),
+		grants:  make(map[enums.UserRole]map[string]bool, len(defs)),
+	}
+	for _, d := range defs {
+		if d.Name == "" {
+			return nil, fmt.Errorf("角色值 %d 缺少 name", d.Value)
+		}
+		if _, dup := h.byName[d.Name]; dup {
+			return nil, fmt.Errorf("角色名 %q 重复", d.Name)
+		}
+		if other, dup := h.byValue[d.Value]; dup {
+			return nil, fmt.Errorf("角色 %q 与 %q 的 value 相同 (%d)", d.Name, other, d.Value)
+		}
+		h.byName[d.Name] = d
+		h.byValue[d.Value] = d.Name
+	}
+
+	for _, d := range defs {
+		granted := make(map[string]bool)
+		if err := h.expand(d.Name, granted, nil); err != nil {
+			return nil, err
+		}
+		h.grants[d.Value] = granted
+	}
+	return h, nil
+}
+
+// expand 把 name 及其继承的全部角色加入 granted，path 用于检测环
+func (h *RoleHierarchy) expand(name string, granted map[string]bool, path []string) error {
+	for _, p := range path {
+		if p == name {
+			return fmt.Errorf("角色继承成环: %s -> %s", strings.Join(path, " -> "), name)
+		}
+	}
+	d, ok := h.byName[name]
+	if !ok {
+		return fmt.Errorf("角色 %q 继承了未定义的角色 %q", path[len(path)-1], name)
+	}
+	granted[name] = true
+	for _, parent := range d.Inherits {
+		if err := h.expand(parent, granted, append(path, name)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Has 判断角色名是否已定义
+func (h *RoleHierarchy) Has(name string) bool {
+	_, ok := h.byName[name]
+	return ok
+}
+
+// Value 返回角色名对应的角色值
+func (h *RoleHierarchy) Value(name string) (enums.UserRole, bool) {
+	d, ok := h.byName[name]
+	return d.Value, ok
+}
+
+// Name 返回角色值对应的角色名，未定义时返回 enums.UserRole 的默认名称
+func (h *RoleHierarchy) Name(role enums.UserRole) string {
+	if name, ok := h.byValue[role]; ok {
+		return name
+	}
+	return role.String()
+}
+
+// Satisfies 判断角色值（含继承）是否具备名为 name 的角色
+func (h *RoleHierarchy) Satisfies(role enums.UserRole, name string) bool {
+	return h.grants[role][name]
+}
+
+// Names 返回全部角色名（排序后）
+func (h *RoleHierarchy) Names() []string {
+	names := make([]string, 0, len(h.byName))
+	for name := range h.byName {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// RoleHierarchy 根据配置构建角色表，未配置 roles 时使用 DefaultRoles
+func (gc *GatewayConfig) RoleHierarchy() (*RoleHierarchy, error) {
+	if len(gc.Roles) == 0 {
+		return NewRoleHierarchy(DefaultRoles)
+	}
+	return NewRoleHierarchy(gc.Roles)
+}
+
+// RequiredRoleNames 返回路由通过 roles / minRole 要求的角色名
+func (r *RouteConfig) RequiredRoleNames() []string {
+	names := append([]string{}, r.Roles...)
+	if r.MinRole != "" {
+		names = append(names, r.MinRole)
+	}
+	return names
+}
+
+// HasRoleRequirement 判断路由是否配置了 allowedRoles / roles / minRole
+func (r *RouteConfig) HasRoleRequirement() bool {
+	return len(r.AllowedRoles) > 0 || len(r.Roles) > 0 || r.MinRole != ""
+}
+
+// Permits 判断身份是否满足路由的角色与授权范围要求
+// - 默认角色匹配或授权范围满足其一即可；scopes.mode 为 all 时两者都要满足
+func (r *RouteConfig) Permits(h *RoleHierarchy, role enums.UserRole, scopes []string) bool {
+	if r.Scopes.RequiresRole() {
+		return r.AllowsRole(h, role) && r.Scopes.SatisfiedBy(scopes)
+	}
+	return r.AllowsRole(h, role) || r.Scopes.SatisfiedBy(scopes)
+}
+
+// AllowsRole 判断角色值是否满足路由的角色要求
+// - allowedRoles 按数值精确匹配（兼容旧配置）
+// - roles / minRole 按角色名匹配并考虑继承：要求 moderator 时 admin 也能访问
+func (r *RouteConfig) AllowsRole(h *RoleHierarchy, role enums.UserRole) bool {
+	for _, allowed := range r.AllowedRoles {
+		if role == allowed {
+			return true
+		}
+	}
+	for _, name := range r.RequiredRoleNames() {
+		if h.Satisfies(role, name) {
+			return true
+		}
+	}
+	return false
+}
@@ -1,14 +1,41 @@
 package config
 
-import "github.com/Xushengqwer/go-common/models/enums"
+import (
+	"strings"
+	"time"
+
+	"github.com/Xushengqwer/go-common/models/enums"
+)
 
 //  todo 每个服务的接口需要在这里写需要什么角色才能访问，需要写一个配置文件
 
 // RouteConfig 定义基于路径的路由规则
+// - Name / Description / Tags / Metadata 只用于标识业务操作：写入日志、审计、统计与 GET /routes，不影响匹配与授权
 type RouteConfig struct {
-	Path         string           `yaml:"path"`              // 资源路径（根据资源路径来选择权限）
-	Methods      []string         `yaml:"methods,omitempty"` // 该路径允许的 HTTP 方法 (GET, POST, PUT, DELETE等), 为空则匹配所有方法
-	AllowedRoles []enums.UserRole `yaml:"allowedRoles"`      // 该路径允许的角色
+	Name         string            `yaml:"name,omitempty"`         // 路由名称（可选，服务内唯一），如 "post.audit"，用作日志字段与统计标签
+	Description  string            `yaml:"description,omitempty"`  // 业务说明（可选）
+	Tags         []string          `yaml:"tags,omitempty"`         // 标签（可选），如 ["admin", "write"]
+	Metadata     map[string]string `yaml:"metadata,omitempty"`     // 任意键值（可选），如 owner / ticket，写入审计与路由列表（配置加载时键名统一转为小写）
+	Path         string            `yaml:"path"`                   // 资源路径模式，支持参数、正则约束、通配与可选段（见 pathpattern 包）
+	Methods      []string          `yaml:"methods,omitempty"`      // 该路径允许的 HTTP 方法 (GET, POST, PUT, DELETE等), 为空或含 "*" 则匹配所有方法
+	AllowedRoles []enums.UserRole  `yaml:"allowedRoles,omitempty"` // 该路径允许的角色
+	Roles        []string          `yaml:"roles,omitempty"`        // 该路径允许的具名角色（见 GatewayConfig.Roles），继承这些角色的角色同样允许
+	MinRole      string            `yaml:"minRole,omitempty"`      // 最低角色：该角色及继承它的角色都允许
+	Scopes       *ScopeRequirement `yaml:"scopes,omitempty"`       // 该路径接受的授权范围（可选），默认与角色要求满足其一即可，mode: all 时都要满足
+	Rewrite      string            `yaml:"rewrite,omitempty"`      // 转发到上游时使用的路径模板（可选），如 "/posts/:id?version=2"，参数取自 Path
+	Mirror       *MirrorConfig     `yaml:"mirror,omitempty"`       // 路由级流量镜像（可选），覆盖服务级配置
+	Limits       *BodyLimit        `yaml:"limits,omitempty"`       // 路由级请求体限制（可选），覆盖服务级配置
+	Timeouts     *TimeoutConfig    `yaml:"timeouts,omitempty"`     // 路由级超时（可选），覆盖服务级配置
+	Auth         []string          `yaml:"auth,omitempty"`         // 接受的认证方式（jwt / mtls / apikey / hmac / basic / none），按顺序尝试，覆盖服务级配置
+	Enforce      *bool             `yaml:"enforce,omitempty"`      // false 表示该路由的权限要求处于试运行：不满足时仅记录，仍然放行（覆盖服务级 enforce）
+}
+
+// RewriteRule 定义服务级的正则路径重写规则
+// - Match 作用于即将发往上游的完整路径（已完成前缀替换）
+// - Replace 支持 $1、${name} 形式引用捕获组，可携带 "?k=v" 追加查询参数
+type RewriteRule struct {
+	Match   string `yaml:"match"`   // 正则表达式
+	Replace string `yaml:"replace"` // 替换模板
 }
 
 // ServiceConfig 定义单个服务的配置
@@ -22,6 +49,165 @@ type ServiceConfig struct {
 	Prefix      string        `yaml:"prefix"`                // 服务路径前缀，示例api/v1
 	Routes      []RouteConfig `yaml:"routes,omitempty"`      // 基于路径的权限（可选）
 	PublicPaths []string      `yaml:"publicPaths,omitempty"` // 公共组路由
+	Auth        []string      `yaml:"auth,omitempty"`        // 私有路由默认接受的认证方式，按顺序尝试，默认 ["jwt"]
+
+	// 访问策略（可选）：显式拒绝规则优先，其余未匹配 publicPaths / routes 的路径按 DefaultPolicy 处理
+	DefaultPolicy string     `yaml:"defaultPolicy,omitempty"` // deny（默认，404）/ authenticated / public
+	Deny          []DenyRule `yaml:"deny,omitempty"`          // 显式拒绝规则，按顺序匹配
+	Enforce       *bool      `yaml:"enforce,omitempty"`       // false 表示整个服务的访问策略处于试运行：本应拒绝的请求仅记录，仍然放行（认证失败仍会拒绝）
+
+	// 上游路径改写（可选）：路由与权限匹配始终基于网关对外的公开路径
+	StripPrefix    bool          `yaml:"stripPrefix,omitempty"`    // 转发前去掉 Prefix，上游无需挂载网关前缀
+	UpstreamPrefix string        `yaml:"upstreamPrefix,omitempty"` // 用该基础路径替换 Prefix（设置后隐含 StripPrefix）
+	Rewrites       []RewriteRule `yaml:"rewrites,omitempty"`       // 正则重写规则，按顺序取第一条命中的规则
+
+	Match *MatchConfig `yaml:"match,omitempty"` // 附加匹配条件（可选），多个服务可共享同一 Prefix
+
+	// 多版本上游（可选，用于金丝雀 / 蓝绿发布）：配置后流量只在各版本间分配，服务自身的 Host/Port 不再使用
+	Versions []UpstreamVersion `yaml:"versions,omitempty"` // 具名上游版本列表
+	Sticky   bool              `yaml:"sticky,omitempty"`   // 匿名请求通过 Cookie 保持版本一致（已认证用户始终按用户 ID 哈希固定）
+
+	Mirror *MirrorConfig `yaml:"mirror,omitempty"` // 服务级流量镜像（可选）
+
+	Cache []CacheRule `yaml:"cache,omitempty"` // 公开 GET 路径的响应缓存规则（需启用 responseCache）
+
+	Compression *CompressionConfig `yaml:"compression,omitempty"` // 服务级压缩配置（可选），覆盖全局 compression 中的对应字段
+
+	Limits *BodyLimit `yaml:"limits,omitempty"` // 服务级请求体限制（可选），覆盖全局 limits.maxBodyBytes
+
+	OpenAPI *OpenAPISource `yaml:"openapi,omitempty"` // 服务的 OpenAPI / Swagger 文档来源（可选），用于聚合文档 docs
+
+	// 上游连接（可选）：每个服务使用独立的 Transport 与连接池
+	Timeouts  *TimeoutConfig     `yaml:"timeouts,omitempty"`  // 服务级超时
+	Transport *TransportConfig   `yaml:"transport,omitempty"` // 连接池设置
+	TLS       *UpstreamTLSConfig `yaml:"tls,omitempty"`       // https 上游的 CA、客户端证书与证书固定
+}
+
+// UpstreamBasePath 返回公开子路径在上游对应的基础路径：upstreamPrefix > stripPrefix（空串）> prefix
+// - 上游路径 = UpstreamBasePath() + 相对 prefix 的子路径（未计入路由 rewrite 与服务级 rewrites）
+func (s *ServiceConfig) UpstreamBasePath() string {
+	basePath := s.Prefix
+	if s.UpstreamPrefix != "" {
+		basePath = "/" + strings.Trim(s.UpstreamPrefix, "/")
+	} else if s.StripPrefix {
+		basePath = ""
+	}
+	return strings.TrimSuffix(basePath, "/")
+}
+
+// MirrorConfig 定义流量镜像（影子流量）配置
+// - 按比例把请求异步复制到镜像上游，镜像响应被丢弃，仅记录与主上游的状态码和耗时差异
+// - 镜像永远不影响客户端响应；请求体超过 MaxBodyBytes 的请求不做镜像
+type MirrorConfig struct {
+	Host         string        `yaml:"host,omitempty"`         // 单机部署的主机地址
+	Port         int           `yaml:"port,omitempty"`         // 服务端口
+	ServiceName  string        `yaml:"serviceName,omitempty"`  // K8s Service 名称
+	Namespace    string        `yaml:"namespace,omitempty"`    // K8s 命名空间
+	Scheme       string        `yaml:"scheme,omitempty"`       // 协议（http 或 https，默认 http）
+	Percent      float64       `yaml:"percent"`                // 镜像比例 (0-100]
+	MaxBodyBytes int64         `yaml:"maxBodyBytes,omitempty"` // 可镜像的最大请求体字节数，默认 1MiB
+	Timeout      time.Duration `yaml:"timeout,omitempty"`      // 镜像请求超时，默认 5s
+}
+
+// MaxVersionWeight 是单个上游版本权重的上限，配置与管理 API 调整权重时都会校验
+// - 按权重分配时用户桶号与累计权重相乘比较，限制权重可避免整数溢出
+const MaxVersionWeight = 10000
+
+// UpstreamVersion 定义服务的一个具名上游版本
+// - 地址字段含义与 ServiceConfig 相同
+// - Match 命中时直接路由到该版本，优先于权重分配；运行时权重可通过管理 API 调整
+type UpstreamVersion struct {
+	Name        string        `yaml:"name"`                  // 版本名称，如 "stable"、"canary"
+	Host        string        `yaml:"host,omitempty"`        // 单机部署的主机地址
+	Port        int           `yaml:"port,omitempty"`        // 服务端口
+	ServiceName string        `yaml:"serviceName,omitempty"` // K8s Service 名称
+	Namespace   string        `yaml:"namespace,omitempty"`   // K8s 命名空间
+	Scheme      string        `yaml:"scheme,omitempty"`      // 协议（http 或 https，默认 http）
+	Weight      int           `yaml:"weight"`                // 流量权重（相对值，0-MaxVersionWeight，0 表示不参与按权重分配）
+	Match       *VersionMatch `yaml:"match,omitempty"`       // 定向路由条件（可选）
+}
+
+// VersionMatch 定义将请求定向到某个版本的条件，任一已配置的条件满足即命中
+type VersionMatch struct {
+	Headers     []FieldMatch     `yaml:"headers,omitempty"`     // 请求头条件
+	Cookies     []FieldMatch     `yaml:"cookies,omitempty"`     // Cookie 条件
+	UserIDs     []string         `yaml:"userIDs,omitempty"`     // 指定用户 ID（如内部员工）
+	UserPercent int              `yaml:"userPercent,omitempty"` // 按用户 ID 哈希落入 [0, UserPercent) 的用户
+	Roles       []enums.UserRole `yaml:"roles,omitempty"`       // 指定角色
+}
+
+// FieldMatch 定义单个请求头或查询参数的匹配条件
+type FieldMatch struct {
+	Name  string `yaml:"name"`            // 请求头或查询参数名
+	Value string `yaml:"value,omitempty"` // 期望值，为空或 "*" 表示只要求存在
+}
+
+// MatchConfig 定义服务的附加匹配条件，已配置的条件需同时满足
+// - 同一 Prefix 下按 Specificity 从高到低尝试，得分相同按配置顺序，未配置 Match 的服务兜底
+type MatchConfig struct {
+	Hosts   []string     `yaml:"hosts,omitempty"`   // 允许的 Host（任一命中即可），支持 "*.example.com" 通配
+	Headers []FieldMatch `yaml:"headers,omitempty"` // 需同时满足的请求头条件
+	Query   []FieldMatch `yaml:"query,omitempty"`   // 需同时满足的查询参数条件
+}
+
+// Specificity 计算匹配条件的具体程度，用于同前缀服务间的优先级排序
+// - 精确 Host 4 分，通配 Host 2 分；每个精确值条件 2 分，仅要求存在的条件 1 分
+func (m *MatchConfig) Specificity() int {
+	if m == nil {
+		return 0
+	}
+	score := 0
+	if len(m.Hosts) > 0 {
+		hostScore := 4
+		for _, host := range m.Hosts {
+			if strings.HasPrefix(host, "*.") {
+				hostScore = 2 // 任一通配 Host 即按通配计分
+				break
+			}
+		}
+		score += hostScore
+	}
+	for _, fields := range [][]FieldMatch{m.Headers, m.Query} {
+		for _, f := range fields {
+			if f.Value == "" || f.Value == "*" {
+				score++
+			} else {
+				score += 2
+			}
+		}
+	}
+	return score
+}
+
+// IsEmpty 判断是否未配置任何匹配条件
+func (m *MatchConfig) IsEmpty() bool {
+	return m == nil || (len(m.Hosts) == 0 && len(m.Headers) == 0 && len(m.Query) == 0)
+}
+
+// Describe 返回匹配条件的可读描述，供校验工具和日志输出
+func (m *MatchConfig) Describe() string {
+	if m.IsEmpty() {
+		return "(默认)"
+	}
+	var parts []string
+	if len(m.Hosts) > 0 {
+		parts = append(parts, "host="+strings.Join(m.Hosts, "|"))
+	}
+	for _, f := range m.Headers {
+		parts = append(parts, "header:"+f.Name+"="+displayValue(f.Value))
+	}
+	for _, f := range m.Query {
+		parts = append(parts, "query:"+f.Name+"="+displayValue(f.Value))
+	}
+	return strings.Join(parts, " && ")
+}
+
+// displayValue 将空期望值显示为 "*"
+func displayValue(v string) string {
+	if v == "" {
+		return "*"
+	}
+	return v
 }
 
 // Config 定义网关的整体配置
@@ -0,0 +1,87 @@
+package config
+
+import (
+	"crypto/tls"
+	"fmt"
+	"time"
+)
+
+// TLSConfig 定义网关的 HTTPS 监听配置
+// - 启用后在 listenAddr 上提供 HTTPS；server.listen_addr 上的 HTTP 监听继续提供服务，或在 redirectHTTP 时只做跳转
+// 例如：
+//
+//	enabled: true
+//	listenAddr: ":8443"
+//	certificates:
+//	  - {certFile: "/etc/gateway/tls/api.crt", keyFile: "/etc/gateway/tls/api.key"}                 // 主机名取自证书 SAN
+//	  - {certFile: "/etc/gateway/tls/m.crt", keyFile: "/etc/gateway/tls/m.key", hosts: ["m.example.com"]}
+//	minVersion: "1.2"
+//	cipherSuites: ["TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256"] // 仅影响 TLS 1.2 及以下
+//	reloadInterval: 30s
+//	redirectHTTP: true
+//	hsts: {maxAge: 8760h, includeSubDomains: true}
+//	clientCAFile: "/etc/gateway/tls/client-ca.crt" // 用于 auth: ["mtls"] 的路由
+type TLSConfig struct {
+	Enabled        bool                `mapstructure:"enabled" json:"enabled" yaml:"enabled"`
+	ListenAddr     string              `mapstructure:"listenAddr" json:"listenAddr" yaml:"listenAddr"`
+	Certificates   []CertificateConfig `mapstructure:"certificates" json:"certificates" yaml:"certificates"`
+	MinVersion     string              `mapstructure:"minVersion" json:"minVersion" yaml:"minVersion"`             // "1.0" / "1.1" / "1.2" / "1.3"，默认 1.2
+	CipherSuites   []string            `mapstructure:"cipherSuites" json:"cipherSuites" yaml:"cipherSuites"`       // Go crypto/tls 中的套件名，为空使用 Go 默认值
+	ReloadInterval time.Duration       `mapstructure:"reloadInterval" json:"reloadInterval" yaml:"reloadInterval"` // 检查证书文件变化的间隔，默认 30s
+	RedirectHTTP   bool                `mapstructure:"redirectHTTP" json:"redirectHTTP" yaml:"redirectHTTP"`       // HTTP 监听只返回 308 跳转到 HTTPS（/health 除外）
+	HSTS           *HSTSConfig         `mapstructure:"hsts" json:"hsts" yaml:"hsts"`                               // 为 HTTPS 响应添加 Strict-Transport-Security
+	ClientCAFile   string              `mapstructure:"clientCAFile" json:"clientCAFile" yaml:"clientCAFile"`       // 校验客户端证书的 CA（可选），客户端可不出示证书
+}
+
+// CertificateConfig 定义一组证书与私钥文件
+type CertificateConfig struct {
+	CertFile string   `mapstructure:"certFile" json:"certFile" yaml:"certFile"`
+	KeyFile  string   `mapstructure:"keyFile" json:"keyFile" yaml:"keyFile"`
+	Hosts    []string `mapstructure:"hosts" json:"hosts" yaml:"hosts"` // SNI 主机名（支持 "*.example.com"），为空时取证书中的 DNS SAN
+}
+
+// HSTSConfig 定义 Strict-Transport-Security 响应头
+type HSTSConfig struct {
+	MaxAge            time.Duration `mapstructure:"maxAge" json:"maxAge" yaml:"maxAge"`
+	IncludeSubDomains bool          `mapstructure:"includeSubDomains" json:"includeSubDomains" yaml:"includeSubDomains"`
+	Preload           bool          `mapstructure:"preload" json:"preload" yaml:"preload"`
+}
+
+// tlsVersions 是 minVersion 可用的取值
+var tlsVersions = map[string]uint16{
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// ParseTLSVersion 把 "1.2" 形式的版本号转换为 crypto/tls 常量，空字符串返回 TLS 1.2
+func ParseTLSVersion(v string) (uint16, error) {
+	if v == "" {
+		return tls.VersionTLS12, nil
+	}
+	if version, ok := tlsVersions[v]; ok {
+		return version, nil
+	}
+	return 0, fmt.Errorf("不支持的 TLS 版本 %q（可选 1.0 / 1.1 / 1.2 / 1.3）", v)
+}
+
+// ParseCipherSuites 把套件名转换为 crypto/tls 的套件 ID，为空时返回 nil 表示使用 Go 默认值
+func ParseCipherSuites(names []string) ([]uint16, error) {
+	if len(names) == 0 {
+		return nil, nil
+	}
+	known := make(map[string]uint16)
+	for _, s := range append(tls.CipherSuites(), tls.InsecureCipherSuites()...) {
+		known[s.Name] = s.ID
+	}
+	ids := make([]uint16, 0, len(names))
+	for _, name := range names {
+		id, ok := known[name]
+		if !ok {
+			return nil, fmt.Errorf("未知的 TLS 套件 %q", name)
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
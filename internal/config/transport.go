@@ -0,0 +1,68 @@
+package config
+
+import "time"
+
+// TimeoutConfig 定义访问上游的超时设置，零值表示继承上一级（路由 -> 服务 -> 默认值）
+// - Overall 覆盖全局 server.requestTimeout，可以比全局值更长或更短
+// 例如：
+//
+//	connect: 1s        // 建立 TCP 连接
+//	tlsHandshake: 2s   // TLS 握手（仅 https 上游）
+//	responseHeader: 3s // 请求发出后等待响应头
+//	overall: 5s        // 整个请求（含认证与读取响应体）
+type TimeoutConfig struct {
+	Connect        time.Duration `yaml:"connect,omitempty"`
+	TLSHandshake   time.Duration `yaml:"tlsHandshake,omitempty"`
+	ResponseHeader time.Duration `yaml:"responseHeader,omitempty"`
+	Overall        time.Duration `yaml:"overall,omitempty"`
+}
+
+// Merge 返回以 t 为默认值、override 中非零字段覆盖后的超时设置
+func (t TimeoutConfig) Merge(override *TimeoutConfig) TimeoutConfig {
+	if override == nil {
+		return t
+	}
+	if override.Connect > 0 {
+		t.Connect = override.Connect
+	}
+	if override.TLSHandshake > 0 {
+		t.TLSHandshake = override.TLSHandshake
+	}
+	if override.ResponseHeader > 0 {
+		t.ResponseHeader = override.ResponseHeader
+	}
+	if override.Overall > 0 {
+		t.Overall = override.Overall
+	}
+	return t
+}
+
+// TransportConfig 定义服务专用 HTTP Transport 的连接池设置，零值使用与 http.DefaultTransport 相同的默认值
+type TransportConfig struct {
+	MaxIdleConns        int           `yaml:"maxIdleConns,omitempty"`        // 所有主机的最大空闲连接数（默认 100）
+	MaxIdleConnsPerHost int           `yaml:"maxIdleConnsPerHost,omitempty"` // 每个主机的最大空闲连接数（默认 2，高并发服务建议调大）
+	MaxConnsPerHost     int           `yaml:"maxConnsPerHost,omitempty"`     // 每个主机的最大连接数，0 表示不限制
+	IdleConnTimeout     time.Duration `yaml:"idleConnTimeout,omitempty"`     // 空闲连接保留时长（默认 90s）
+	KeepAlive           time.Duration `yaml:"keepAlive,omitempty"`           // TCP keep-alive 探测间隔（默认 30s）
+	DisableKeepAlives   bool          `yaml:"disableKeepAlives,omitempty"`   // 每个请求使用新连接
+	HTTP2               *bool         `yaml:"http2,omitempty"`               // 是否尝试 HTTP/2（默认 true，仅对 https 上游生效）
+}
+
+// UpstreamTLSConfig 定义访问 https 上游时的 TLS 设置，用于网关与内部服务之间的双向认证
+// - 配置 caFile 后只信任该 CA 签发的上游证书（不再使用系统根证书）
+// - pinnedSHA256 为上游证书链中任一证书公钥（SPKI）的 SHA-256 摘要（base64），在常规校验之外额外比对
+// 例如：
+//
+//	caFile: "/etc/gateway/upstream/ca.crt"
+//	certFile: "/etc/gateway/upstream/gateway.crt" // 出示给上游的客户端证书
+//	keyFile: "/etc/gateway/upstream/gateway.key"
+//	serverName: "user-hub-service.internal"
+//	pinnedSHA256: ["47DEQpj8HBSa+/TImW+5JCeuQeRkm5NMpJWZG3hSuFU="]
+type UpstreamTLSConfig struct {
+	CAFile       string   `yaml:"caFile,omitempty"`
+	CertFile     string   `yaml:"certFile,omitempty"`
+	KeyFile      string   `yaml:"keyFile,omitempty"`
+	ServerName   string   `yaml:"serverName,omitempty"` // 覆盖 SNI 与证书校验使用的主机名（如 K8s Service DNS 与证书名不一致时）
+	MinVersion   string   `yaml:"minVersion,omitempty"` // 默认 1.2
+	PinnedSHA256 []string `yaml:"pinnedSHA256,omitempty"`
+}
@@ -0,0 +1,547 @@
+package config
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/Xushengqwer/gateway/internal/pathpattern"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// ServiceGroup 表示共享同一 Prefix 的一组服务，Services 已按匹配优先级排序
+type ServiceGroup struct {
+	Prefix   string
+	Services []ServiceConfig
+}
+
+// ServiceGroups 将服务按 Prefix 分组并按确定的优先级排序
+// - 组按 Prefix 首次出现的顺序排列
+// - 组内按 Match.Specificity 从高到低排序，得分相同保持配置顺序（稳定排序）
+func (gc *GatewayConfig) ServiceGroups() []ServiceGroup {
+	var groups []ServiceGroup
+	index := make(map[string]int)
+	for _, svc := range gc.Services {
+		prefix := NormalizePrefix(svc.Prefix)
+		i, ok := index[prefix]
+		if !ok {
+			i = len(groups)
+			index[prefix] = i
+			groups = append(groups, ServiceGroup{Prefix: prefix})
+		}
+		groups[i].Services = append(groups[i].Services, svc)
+	}
+
+	for i := range groups {
+		services := groups[i].Services
+		sort.SliceStable(services, func(a, b int) bool {
+			return services[a].Match.Specificity() > services[b].Match.Specificity()
+		})
+	}
+	return groups
+}
+
+// NormalizePrefix 统一服务前缀格式：以 "/" 开头且不以 "/" 结尾（根前缀为空串）
+func NormalizePrefix(prefix string) string {
+	trimmed := strings.Trim(prefix, "/")
+	if trimmed == "" {
+		return ""
+	}
+	return "/" + trimmed
+}
+
+// Validate 校验网关配置，返回汇总后的全部错误
+func (gc *GatewayConfig) Validate() error {
+	var errs []error
+	names := make(map[string]bool)
+
+	roles, err := gc.RoleHierarchy()
+	if err != nil {
+		errs = append(errs, fmt.Errorf("roles: %w", err))
+	}
+
+	for i, svc := range gc.Services {
+		label := fmt.Sprintf("services[%d](%s)", i, svc.Name)
+		if svc.Name == "" {
+			errs = append(errs, fmt.Errorf("%s: name 不能为空", label))
+		} else if names[svc.Name] {
+			errs = append(errs, fmt.Errorf("%s: 服务名重复", label))
+		}
+		names[svc.Name] = true
+
+		if len(svc.Versions) == 0 && svc.ServiceName == "" && (svc.Host == "" || svc.Port == 0) {
+			errs = append(errs, fmt.Errorf("%s: 非 K8s 模式下必须同时指定 host 与 port", label))
+		}
+		versionNames := make(map[string]bool)
+		for j, v := range svc.Versions {
+			switch {
+			case v.Name == "":
+				errs = append(errs, fmt.Errorf("%s: versions[%d] 缺少 name", label, j))
+			case versionNames[v.Name]:
+				errs = append(errs, fmt.Errorf("%s: versions[%d] 版本名 %s 重复", label, j, v.Name))
+			}
+			versionNames[v.Name] = true
+			if v.ServiceName == "" && (v.Host == "" || v.Port == 0) {
+				errs = append(errs, fmt.Errorf("%s: versions[%d](%s) 非 K8s 模式下必须同时指定 host 与 port", label, j, v.Name))
+			}
+			if v.Weight < 0 || v.Weight > MaxVersionWeight {
+				errs = append(errs, fmt.Errorf("%s: versions[%d](%s) weight 必须在 0-%d 之间", label, j, v.Name, MaxVersionWeight))
+			}
+			if v.Match != nil && (v.Match.UserPercent < 0 || v.Match.UserPercent > 100) {
+				errs = append(errs, fmt.Errorf("%s: versions[%d](%s) match.userPercent 必须在 0-100 之间", label, j, v.Name))
+			}
+		}
+		if err := validateMirror(svc.Mirror); err != nil {
+			errs = append(errs, fmt.Errorf("%s: mirror %w", label, err))
+		}
+		for j, route := range svc.Routes {
+			if err := validateMirror(route.Mirror); err != nil {
+				errs = append(errs, fmt.Errorf("%s: routes[%d](%s) mirror %w", label, j, route.Path, err))
+			}
+		}
+		for j, rule := range svc.Rewrites {
+			if _, err := regexp.Compile(rule.Match); err != nil {
+				errs = append(errs, fmt.Errorf("%s: rewrites[%d] 正则无效: %w", label, j, err))
+			}
+		}
+		if err := validateOpenAPISource(svc.OpenAPI); err != nil {
+			errs = append(errs, fmt.Errorf("%s: openapi %w", label, err))
+		}
+		if len(svc.Cache) > 0 && gc.ResponseCache == nil {
+			errs = append(errs, fmt.Errorf("%s: 配置了 cache 规则但未启用全局 responseCache", label))
+		}
+		for j, rule := range svc.Cache {
+			if rule.Path == "" {
+				errs = append(errs, fmt.Errorf("%s: cache[%d] 缺少 path", label, j))
+			} else if err := validatePathPattern(rule.Path); err != nil {
+				errs = append(errs, fmt.Errorf("%s: cache[%d] %w", label, j, err))
+			}
+			if rule.TTL < 0 || rule.StaleWhileRevalidate < 0 {
+				errs = append(errs, fmt.Errorf("%s: cache[%d](%s) ttl 与 staleWhileRevalidate 不能为负数", label, j, rule.Path))
+			}
+		}
+		if err := validateBodyLimit(gc.Limits.MaxBodyBytes, svc.Limits, nil); err != nil {
+			errs = append(errs, fmt.Errorf("%s: limits %w", label, err))
+		}
+		for j := range svc.Routes {
+			if err := validateBodyLimit(gc.Limits.MaxBodyBytes, svc.Limits, svc.Routes[j].Limits); err != nil {
+				errs = append(errs, fmt.Errorf("%s: routes[%d](%s) limits %w", label, j, svc.Routes[j].Path, err))
+			}
+		}
+		if err := validateTimeouts(svc.Timeouts); err != nil {
+			errs = append(errs, fmt.Errorf("%s: timeouts %w", label, err))
+		}
+		for j, route := range svc.Routes {
+			if err := validateTimeouts(route.Timeouts); err != nil {
+				errs = append(errs, fmt.Errorf("%s: routes[%d](%s) timeouts %w", label, j, route.Path, err))
+			}
+		}
+		if t := svc.Transport; t != nil && (t.MaxIdleConns < 0 || t.MaxIdleConnsPerHost < 0 || t.MaxConnsPerHost < 0 ||
+			t.IdleConnTimeout < 0 || t.KeepAlive < 0) {
+			errs = append(errs, fmt.Errorf("%s: transport 中的数值不能为负数", label))
+		}
+		if err := validateUpstreamTLS(svc); err != nil {
+			errs = append(errs, fmt.Errorf("%s: tls %w", label, err))
+		}
+		if err := gc.validateAuthSchemes(svc.Auth); err != nil {
+			errs = append(errs, fmt.Errorf("%s: auth %w", label, err))
+		}
+		switch svc.DefaultPolicy {
+		case "", DefaultPolicyDeny, DefaultPolicyAuthenticated, DefaultPolicyPublic:
+		default:
+			errs = append(errs, fmt.Errorf("%s: defaultPolicy 仅支持 deny / authenticated / public: %s", label, svc.DefaultPolicy))
+		}
+		for j, rule := range svc.Deny {
+			if err := validatePathPattern(rule.Path); err != nil {
+				errs = append(errs, fmt.Errorf("%s: deny[%d] %w", label, j, err))
+			}
+			if err := validateMethods(rule.Methods); err != nil {
+				errs = append(errs, fmt.Errorf("%s: deny[%d](%s) %w", label, j, rule.Path, err))
+			}
+			for _, name := range rule.ExceptRoles {
+				if roles != nil && !roles.Has(name) {
+					errs = append(errs, fmt.Errorf("%s: deny[%d](%s) exceptRoles 引用了未定义的角色 %q", label, j, rule.Path, name))
+				}
+			}
+		}
+		for _, pattern := range svc.PublicPaths {
+			if err := validatePathPattern(pattern); err != nil {
+				errs = append(errs, fmt.Errorf("%s: publicPaths %w", label, err))
+			}
+		}
+		routeNames := make(map[string]int)
+		for j, route := range svc.Routes {
+			if route.Name != "" {
+				if !routeNamePattern.MatchString(route.Name) {
+					errs = append(errs, fmt.Errorf("%s: routes[%d](%s) name 只能包含字母、数字与 _ . : -: %q", label, j, route.Path, route.Name))
+				} else if prev, dup := routeNames[route.Name]; dup {
+					errs = append(errs, fmt.Errorf("%s: routes[%d](%s) name %q 与 routes[%d] 重复", label, j, route.Path, route.Name, prev))
+				}
+				routeNames[route.Name] = j
+			}
+			for _, tag := range route.Tags {
+				if tag == "" || strings.ContainsAny(tag, " \t,") {
+					errs = append(errs, fmt.Errorf("%s: routes[%d](%s) tags 含有空值、空白或逗号: %q", label, j, route.Path, tag))
+				}
+			}
+			if err := validatePathPattern(route.Path); err != nil {
+				errs = append(errs, fmt.Errorf("%s: routes[%d] %w", label, j, err))
+			}
+			if err := validateMethods(route.Methods); err != nil {
+				errs = append(errs, fmt.Errorf("%s: routes[%d](%s) %w", label, j, route.Path, err))
+			}
+			if err := gc.validateAuthSchemes(route.Auth); err != nil {
+				errs = append(errs, fmt.Errorf("%s: routes[%d](%s) auth %w", label, j, route.Path, err))
+			}
+			for _, name := range route.RequiredRoleNames() {
+				if roles != nil && !roles.Has(name) {
+					errs = append(errs, fmt.Errorf("%s: routes[%d](%s) 引用了未定义的角色 %q (可用: %s)", label, j, route.Path, name, strings.Join(roles.Names(), ", ")))
+				}
+			}
+			if route.Scopes != nil {
+				for _, scope := range append(append([]string{}, route.Scopes.AllOf...), route.Scopes.AnyOf...) {
+					if scope == "" || strings.ContainsAny(scope, " \t") {
+						errs = append(errs, fmt.Errorf("%s: routes[%d](%s) scopes 含有空值或空白字符: %q", label, j, route.Path, scope))
+					}
+				}
+				switch route.Scopes.Mode {
+				case "", ScopeModeAny:
+				case ScopeModeAll:
+					if route.Scopes.Empty() || !route.HasRoleRequirement() {
+						errs = append(errs, fmt.Errorf("%s: routes[%d](%s) scopes.mode 为 all 时需要同时配置授权范围与 allowedRoles / roles / minRole", label, j, route.Path))
+					}
+				default:
+					errs = append(errs, fmt.Errorf("%s: routes[%d](%s) scopes.mode 只能是 any 或 all: %q", label, j, route.Path, route.Scopes.Mode))
+				}
+			}
+		}
+		if svc.Match != nil {
+			for _, host := range svc.Match.Hosts {
+				if strings.Contains(strings.TrimPrefix(host, "*."), "*") {
+					errs = append(errs, fmt.Errorf("%s: match.hosts 仅支持 \"*.\" 前缀通配: %s", label, host))
+				}
+			}
+			for _, f := range append(append([]FieldMatch{}, svc.Match.Headers...), svc.Match.Query...) {
+				if f.Name == "" {
+					errs = append(errs, fmt.Errorf("%s: match 条件缺少 name", label))
+				}
+			}
+		}
+	}
+
+	if gc.TLS != nil && gc.TLS.Enabled {
+		if err := validateTLS(gc.TLS, gc.Server.ListenAddr); err != nil {
+			errs = append(errs, fmt.Errorf("tls: %w", err))
+		}
+	}
+
+	if err := validateAudit(gc.Audit); err != nil {
+		errs = append(errs, fmt.Errorf("audit: %w", err))
+	}
+
+	if gc.ClientCert != nil {
+		for i, id := range gc.ClientCert.Identities {
+			if id.CommonName == "" && id.SAN == "" {
+				errs = append(errs, fmt.Errorf("clientCertAuth: identities[%d] 必须指定 commonName 或 san", i))
+			}
+			if id.UserID == "" {
+				errs = append(errs, fmt.Errorf("clientCertAuth: identities[%d] 缺少 userID", i))
+			}
+		}
+	}
+
+	if gc.HMACAuth != nil {
+		if gc.HMACAuth.MaxSkew < 0 || gc.HMACAuth.MaxBodyBytes < 0 {
+			errs = append(errs, errors.New("hmacAuth: maxSkew 与 maxBodyBytes 不能为负数"))
+		}
+		keyIDs := make(map[string]bool)
+		for i, k := range gc.HMACAuth.Keys {
+			switch {
+			case k.KeyID == "":
+				errs = append(errs, fmt.Errorf("hmacAuth: keys[%d] 缺少 keyID", i))
+			case keyIDs[k.KeyID]:
+				errs = append(errs, fmt.Errorf("hmacAuth: keys[%d] keyID %q 重复", i, k.KeyID))
+			}
+			keyIDs[k.KeyID] = true
+			if len(k.Secret) < 16 {
+				errs = append(errs, fmt.Errorf("hmacAuth: keys[%d](%s) secret 至少 16 个字符", i, k.KeyID))
+			}
+		}
+	}
+
+	if gc.BasicAuth != nil {
+		users := make(map[string]bool)
+		for i, u := range gc.BasicAuth.Users {
+			switch {
+			case u.Username == "" || strings.Contains(u.Username, ":"):
+				errs = append(errs, fmt.Errorf("basicAuth: users[%d] username 不能为空且不能包含冒号", i))
+			case users[u.Username]:
+				errs = append(errs, fmt.Errorf("basicAuth: users[%d] username %q 重复", i, u.Username))
+			}
+			users[u.Username] = true
+			if _, err := bcrypt.Cost([]byte(u.PasswordHash)); err != nil {
+				errs = append(errs, fmt.Errorf("basicAuth: users[%d](%s) passwordHash 不是有效的 bcrypt 哈希", i, u.Username))
+			}
+		}
+	}
+
+	if gc.APIKeys != nil {
+		if gc.APIKeys.StoreFile == "" {
+			errs = append(errs, errors.New("apiKeys: 必须配置 storeFile"))
+		}
+		planNames := make([]string, 0, len(gc.APIKeys.Plans))
+		for name := range gc.APIKeys.Plans {
+			planNames = append(planNames, name)
+		}
+		sort.Strings(planNames)
+		for _, name := range planNames {
+			if plan := gc.APIKeys.Plans[name]; plan.Capacity <= 0 || plan.RefillInterval <= 0 {
+				errs = append(errs, fmt.Errorf("apiKeys: plans.%s 的 capacity 与 refillInterval 必须大于 0", name))
+			}
+		}
+	}
+
+	adminPrefix := gc.Admin.PathPrefix()
+	if gc.Admin.Enabled && gc.Admin.Token == "" {
+		errs = append(errs, errors.New("admin: 启用管理 API 时必须配置 token"))
+	}
+	docsEnabled := gc.Docs != nil && gc.Docs.Enabled
+	if docsEnabled && gc.Admin.Enabled && prefixesOverlap(gc.Docs.PathPrefix(), adminPrefix) {
+		errs = append(errs, fmt.Errorf("文档路径 %q 与管理 API 前缀 %q 冲突", gc.Docs.PathPrefix(), adminPrefix))
+	}
+	for _, group := range gc.ServiceGroups() {
+		if gc.Admin.Enabled && prefixesOverlap(adminPrefix, group.Prefix) {
+			errs = append(errs, fmt.Errorf("管理 API 前缀 %q 与服务前缀 %q 冲突", adminPrefix, group.Prefix))
+		}
+		if docsEnabled && prefixesOverlap(gc.Docs.PathPrefix(), group.Prefix) {
+			errs = append(errs, fmt.Errorf("文档路径 %q 与服务前缀 %q 冲突", gc.Docs.PathPrefix(), group.Prefix))
+		}
+		seen := make(map[string]string)
+		for _, svc := range group.Services {
+			key := svc.Match.Describe()
+			if other, ok := seen[key]; ok {
+				errs = append(errs, fmt.Errorf("前缀 %q 下服务 %s 与 %s 的匹配条件相同 (%s)，后者永远不会被选中",
+					group.Prefix, other, svc.Name, key))
+				continue
+			}
+			seen[key] = svc.Name
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// prefixesOverlap 判断两个规范化前缀是否按整段互相包含，空串（根前缀）与任何前缀重叠
+func prefixesOverlap(a, b string) bool {
+	return a == "" || b == "" || strings.HasPrefix(a+"/", b+"/") || strings.HasPrefix(b+"/", a+"/")
+}
+
+// validateOpenAPISource 校验服务文档来源：file 与 url 二选一，url 为绝对地址或以 "/" 开头的上游路径
+func validateOpenAPISource(src *OpenAPISource) error {
+	if src == nil {
+		return nil
+	}
+	if (src.File == "") == (src.URL == "") {
+		return errors.New("file 与 url 必须且只能配置一个")
+	}
+	if src.URL != "" && !strings.HasPrefix(src.URL, "/") {
+		u, err := url.Parse(src.URL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("url 必须是 http(s) 地址或以 \"/\" 开头的上游路径: %q", src.URL)
+		}
+	}
+	if src.BasePath != "" && !strings.HasPrefix(src.BasePath, "/") {
+		return fmt.Errorf("basePath 必须以 \"/\" 开头: %q", src.BasePath)
+	}
+	return nil
+}
+
+// validateAuthSchemes 校验服务或路由的认证方式：只允许已知方式，且所需的全局配置已提供
+// - mtls 需要 HTTPS 监听配置了 clientCAFile 与身份映射，apikey 需要 apiKeys，hmac 需要 hmacAuth，basic 需要 basicAuth
+func (gc *GatewayConfig) validateAuthSchemes(schemes []string) error {
+	seen := make(map[string]bool, len(schemes))
+	for i, scheme := range schemes {
+		if seen[scheme] {
+			return fmt.Errorf("认证方式 %q 重复", scheme)
+		}
+		seen[scheme] = true
+		switch scheme {
+		case AuthSchemeJWT:
+		case AuthSchemeMTLS:
+			if gc.TLS == nil || !gc.TLS.Enabled || gc.TLS.ClientCAFile == "" {
+				return errors.New("使用 mtls 时必须启用 tls 并配置 tls.clientCAFile")
+			}
+			if gc.ClientCert == nil || len(gc.ClientCert.Identities) == 0 {
+				return errors.New("使用 mtls 时必须配置 clientCertAuth.identities")
+			}
+		case AuthSchemeAPIKey:
+			if gc.APIKeys == nil {
+				return errors.New("使用 apikey 时必须配置 apiKeys")
+			}
+		case AuthSchemeHMAC:
+			if gc.HMACAuth == nil || len(gc.HMACAuth.Keys) == 0 {
+				return errors.New("使用 hmac 时必须配置 hmacAuth.keys")
+			}
+		case AuthSchemeBasic:
+			if gc.BasicAuth == nil || len(gc.BasicAuth.Users) == 0 {
+				return errors.New("使用 basic 时必须配置 basicAuth.users")
+			}
+		case AuthSchemeNone:
+			if i != len(schemes)-1 {
+				return errors.New("none 总是成功，只能放在最后")
+			}
+		default:
+			return fmt.Errorf("未知的认证方式 %q", scheme)
+		}
+	}
+	return nil
+}
+
+// routeNamePattern 限制路由名称的字符，使其可直接用作日志字段与统计标签
+var routeNamePattern = regexp.MustCompile(`^[A-Za-z0-9_.:-]+$`)
+
+// validatePathPattern 校验 publicPaths / routes / deny / cache 使用的路径模式，语法见 pathpattern 包
+func validatePathPattern(pattern string) error {
+	_, err := pathpattern.Parse(pattern)
+	return err
+}
+
+// validateMethods 校验规则的 methods：只允许标准 HTTP 方法与通配 "*"
+func validateMethods(methods []string) error {
+	for _, m := range methods {
+		if m == MethodWildcard {
+			continue
+		}
+		switch strings.ToUpper(m) {
+		case http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch,
+			http.MethodDelete, http.MethodConnect, http.MethodOptions, http.MethodTrace:
+		default:
+			return fmt.Errorf("未知的 HTTP 方法 %q", m)
+		}
+	}
+	return nil
+}
+
+// validateAudit 校验审计日志配置
+func validateAudit(a *AuditConfig) error {
+	if a == nil || !a.Enabled {
+		return nil
+	}
+	if a.File == "" {
+		return fmt.Errorf("启用审计日志时 file 不能为空")
+	}
+	if a.MaxSizeMB < 0 || a.MaxBackups < 0 {
+		return fmt.Errorf("maxSizeMB 与 maxBackups 不能为负数")
+	}
+	if rate := a.AllowedSampleRate(); rate < 0 || rate > 1 {
+		return fmt.Errorf("sampleAllowed 必须在 [0,1] 范围内: %v", rate)
+	}
+	return nil
+}
+
+// validateBodyLimit 校验请求体限制：检查 multipart 文件类型需要缓冲请求体，因此必须有大小上限
+func validateBodyLimit(globalMax int64, svc, route *BodyLimit) error {
+	for _, l := range []*BodyLimit{svc, route} {
+		if l != nil && l.MaxBodyBytes < 0 {
+			return errors.New("maxBodyBytes 不能为负数")
+		}
+	}
+	if merged := MergeBodyLimits(globalMax, svc, route); len(merged.AllowedPartTypes) > 0 && merged.MaxBodyBytes == 0 {
+		return errors.New("配置 allowedPartTypes 时必须设置 maxBodyBytes")
+	}
+	return nil
+}
+
+// validateTLS 校验 HTTPS 监听配置（不检查证书文件是否存在，启动时加载失败会直接报错）
+func validateTLS(t *TLSConfig, httpAddr string) error {
+	var errs []error
+	if t.ListenAddr == "" {
+		errs = append(errs, errors.New("启用 TLS 时必须配置 listenAddr"))
+	} else if t.ListenAddr == httpAddr {
+		errs = append(errs, fmt.Errorf("listenAddr %q 与 server.listen_addr 相同", t.ListenAddr))
+	}
+	if len(t.Certificates) == 0 {
+		errs = append(errs, errors.New("启用 TLS 时至少需要一组证书"))
+	}
+	for i, c := range t.Certificates {
+		if c.CertFile == "" || c.KeyFile == "" {
+			errs = append(errs, fmt.Errorf("certificates[%d] 必须同时指定 certFile 与 keyFile", i))
+		}
+	}
+	if _, err := ParseTLSVersion(t.MinVersion); err != nil {
+		errs = append(errs, err)
+	}
+	if _, err := ParseCipherSuites(t.CipherSuites); err != nil {
+		errs = append(errs, err)
+	}
+	if t.RedirectHTTP && httpAddr == "" {
+		errs = append(errs, errors.New("redirectHTTP 需要配置 server.listen_addr 作为 HTTP 监听地址"))
+	}
+	return errors.Join(errs...)
+}
+
+// validateUpstreamTLS 校验服务的上游 TLS 配置，未配置时返回 nil
+func validateUpstreamTLS(svc ServiceConfig) error {
+	t := svc.TLS
+	if t == nil {
+		return nil
+	}
+	var errs []error
+	https := svc.Scheme == "https"
+	for _, v := range svc.Versions {
+		https = https || v.Scheme == "https"
+	}
+	if !https {
+		errs = append(errs, errors.New("仅对 scheme 为 https 的上游生效"))
+	}
+	if (t.CertFile == "") != (t.KeyFile == "") {
+		errs = append(errs, errors.New("certFile 与 keyFile 必须同时配置"))
+	}
+	if _, err := ParseTLSVersion(t.MinVersion); err != nil {
+		errs = append(errs, err)
+	}
+	for _, pin := range t.PinnedSHA256 {
+		if raw, err := base64.StdEncoding.DecodeString(pin); err != nil || len(raw) != sha256.Size {
+			errs = append(errs, fmt.Errorf("pinnedSHA256 %q 不是 base64 编码的 SHA-256 摘要", pin))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// validateTimeouts 校验超时配置，未配置时返回 nil
+func validateTimeouts(t *TimeoutConfig) error {
+	if t == nil {
+		return nil
+	}
+	if t.Connect < 0 || t.TLSHandshake < 0 || t.ResponseHeader < 0 || t.Overall < 0 {
+		return errors.New("不能为负数")
+	}
+	if t.Overall > 0 && t.ResponseHeader > t.Overall {
+		return errors.New("responseHeader 不能大于 overall")
+	}
+	return nil
+}
+
+// validateMirror 校验流量镜像配置，未配置时返回 nil
+func validateMirror(m *MirrorConfig) error {
+	if m == nil {
+		return nil
+	}
+	if m.ServiceName == "" && (m.Host == "" || m.Port == 0) {
+		return errors.New("非 K8s 模式下必须同时指定 host 与 port")
+	}
+	if m.Percent <= 0 || m.Percent > 100 {
+		return errors.New("percent 必须在 (0, 100] 之间")
+	}
+	if m.MaxBodyBytes < 0 {
+		return errors.New("maxBodyBytes 不能为负数")
+	}
+	return nil
+}
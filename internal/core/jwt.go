@@ -6,6 +6,7 @@ import (
 	"github.com/Xushengqwer/gateway/internal/config"
 	"github.com/Xushengqwer/go-common/core"
 	"github.com/Xushengqwer/go-common/models/enums"
+	"strings"
 
 	"go.uber.org/zap"
 
@@ -24,13 +25,28 @@ type JWTUtilityInterface interface {
 
 // CustomClaims 定义 JWT 的声明结构体，包含标准字段和自定义字段
 type CustomClaims struct {
-	UserID               string           `json:"user_id"`  // 用户ID，唯一标识用户
-	Role                 enums.UserRole   `json:"role"`     // 用户角色，例如管理员或普通用户
-	Status               enums.UserStatus `json:"status"`   // 用户状态，例如活跃或禁用
-	Platform             enums.Platform   `json:"platform"` // 客户端平台，例如 Web 或微信小程序
+	UserID               string           `json:"user_id"`               // 用户ID，唯一标识用户
+	Role                 enums.UserRole   `json:"role"`                  // 用户角色，例如管理员或普通用户
+	Status               enums.UserStatus `json:"status"`                // 用户状态，例如活跃或禁用
+	Platform             enums.Platform   `json:"platform"`              // 客户端平台，例如 Web 或微信小程序
+	Scope                string           `json:"scope,omitempty"`       // OAuth 风格的授权范围，空格分隔，如 "post:audit post:read"
+	Permissions          []string         `json:"permissions,omitempty"` // 细粒度权限列表，与 Scope 合并使用
 	jwt.RegisteredClaims                  // 嵌入 JWT v5 的标准声明字段
 }
 
+// Scopes 合并 scope 与 permissions 声明，返回去重后的授权范围
+func (c *CustomClaims) Scopes() []string {
+	var scopes []string
+	seen := make(map[string]bool)
+	for _, s := range append(strings.Fields(c.Scope), c.Permissions...) {
+		if s != "" && !seen[s] {
+			seen[s] = true
+			scopes = append(scopes, s)
+		}
+	}
+	return scopes
+}
+
 // JWTUtility 实现 JWTUtilityInterface 接口的结构体
 type JWTUtility struct {
 	cfg    *config.GatewayConfig // JWT 配置，包含密钥、发行者等信息
@@ -0,0 +1,164 @@
+package listener
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Xushengqwer/gateway/internal/config"
+	sharedCore "github.com/Xushengqwer/go-common/core"
+
+	"go.uber.org/zap"
+)
+
+// defaultReloadInterval 是检查证书文件变化的默认间隔
+const defaultReloadInterval = 30 * time.Second
+
+// certEntry 是一组已加载的证书及其文件状态
+type certEntry struct {
+	cfg     config.CertificateConfig
+	cert    *tls.Certificate
+	hosts   []string // 小写的 SNI 主机名，可能包含 "*." 通配
+	modTime time.Time
+}
+
+// CertStore 保存网关的服务端证书，按 SNI 选择证书并在文件变化时自动重新加载
+// - 重新加载失败时保留旧证书继续服务，只记录错误日志
+type CertStore struct {
+	mu      sync.RWMutex
+	entries []*certEntry
+	logger  *sharedCore.ZapLogger
+}
+
+// NewCertStore 加载配置中的所有证书，任一证书加载失败即返回错误
+func NewCertStore(certs []config.CertificateConfig, logger *sharedCore.ZapLogger) (*CertStore, error) {
+	if len(certs) == 0 {
+		return nil, errors.New("未配置任何证书")
+	}
+	store := &CertStore{logger: logger}
+	for _, c := range certs {
+		entry, err := loadCertEntry(c)
+		if err != nil {
+			return nil, err
+		}
+		store.entries = append(store.entries, entry)
+	}
+	return store, nil
+}
+
+// GetCertificate 实现 tls.Config.GetCertificate：精确主机名优先，其次单级通配，都不匹配时返回第一张证书
+func (s *CertStore) GetCertificate(hello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	name := strings.ToLower(strings.TrimSuffix(hello.ServerName, "."))
+	if name != "" {
+		for _, e := range s.entries {
+			for _, h := range e.hosts {
+				if h == name {
+					return e.cert, nil
+				}
+			}
+		}
+		if i := strings.IndexByte(name, '.'); i > 0 {
+			wildcard := "*" + name[i:]
+			for _, e := range s.entries {
+				for _, h := range e.hosts {
+					if h == wildcard {
+						return e.cert, nil
+					}
+				}
+			}
+		}
+	}
+	return s.entries[0].cert, nil
+}
+
+// WatchReload 按 interval 轮询证书文件的修改时间，变化时重新加载，直到 stop 被关闭
+// - 使用轮询而非文件事件，兼容 K8s Secret 通过符号链接原子替换文件的方式
+func (s *CertStore) WatchReload(interval time.Duration, stop <-chan struct{}) {
+	if interval <= 0 {
+		interval = defaultReloadInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			s.reloadChanged()
+		}
+	}
+}
+
+// reloadChanged 重新加载修改时间发生变化的证书
+func (s *CertStore) reloadChanged() {
+	s.mu.RLock()
+	entries := append([]*certEntry(nil), s.entries...)
+	s.mu.RUnlock()
+
+	for i, old := range entries {
+		modTime, err := latestModTime(old.cfg)
+		if err != nil {
+			s.logger.Error("检查证书文件失败", zap.String("certFile", old.cfg.CertFile), zap.Error(err))
+			continue
+		}
+		if !modTime.After(old.modTime) {
+			continue
+		}
+		entry, err := loadCertEntry(old.cfg)
+		if err != nil {
+			s.logger.Error("重新加载证书失败，继续使用旧证书", zap.String("certFile", old.cfg.CertFile), zap.Error(err))
+			continue
+		}
+		s.mu.Lock()
+		s.entries[i] = entry
+		s.mu.Unlock()
+		s.logger.Info("证书已重新加载",
+			zap.String("certFile", old.cfg.CertFile),
+			zap.Strings("hosts", entry.hosts),
+			zap.Time("notAfter", entry.cert.Leaf.NotAfter))
+	}
+}
+
+// loadCertEntry 读取证书与私钥，并确定其 SNI 主机名
+func loadCertEntry(c config.CertificateConfig) (*certEntry, error) {
+	modTime, err := latestModTime(c)
+	if err != nil {
+		return nil, err
+	}
+	cert, err := tls.LoadX509KeyPair(c.CertFile, c.KeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("加载证书 %s 失败: %w", c.CertFile, err)
+	}
+
+	hosts := c.Hosts
+	if len(hosts) == 0 && cert.Leaf != nil {
+		hosts = cert.Leaf.DNSNames
+	}
+	lower := make([]string, 0, len(hosts))
+	for _, h := range hosts {
+		lower = append(lower, strings.ToLower(h))
+	}
+	return &certEntry{cfg: c, cert: &cert, hosts: lower, modTime: modTime}, nil
+}
+
+// latestModTime 返回证书与私钥文件中较新的修改时间
+func latestModTime(c config.CertificateConfig) (time.Time, error) {
+	var latest time.Time
+	for _, f := range []string{c.CertFile, c.KeyFile} {
+		info, err := os.Stat(f)
+		if err != nil {
+			return time.Time{}, err
+		}
+		if info.ModTime().After(latest) {
+			latest = info.ModTime()
+		}
+	}
+	return latest, nil
+}
@@ -0,0 +1,66 @@
+package listener
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+
+	"github.com/Xushengqwer/gateway/internal/config"
+)
+
+// NewTLSConfig 根据 HTTPS 监听配置与证书仓库构建 tls.Config
+func NewTLSConfig(cfg *config.TLSConfig, store *CertStore) (*tls.Config, error) {
+	minVersion, err := config.ParseTLSVersion(cfg.MinVersion)
+	if err != nil {
+		return nil, err
+	}
+	suites, err := config.ParseCipherSuites(cfg.CipherSuites)
+	if err != nil {
+		return nil, err
+	}
+	tlsConfig := &tls.Config{
+		MinVersion:     minVersion,
+		CipherSuites:   suites,
+		GetCertificate: store.GetCertificate,
+		NextProtos:     []string{"h2", "http/1.1"},
+	}
+	if cfg.ClientCAFile != "" {
+		pem, err := os.ReadFile(cfg.ClientCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("读取客户端 CA 文件失败: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("客户端 CA 文件 %s 中没有有效的 PEM 证书", cfg.ClientCAFile)
+		}
+		// 证书是可选的身份来源：未出示证书的客户端仍可使用 JWT，出示的证书必须能被校验
+		tlsConfig.ClientCAs = pool
+		tlsConfig.ClientAuth = tls.VerifyClientCertIfGiven
+	}
+	return tlsConfig, nil
+}
+
+// RedirectHandler 返回把 HTTP 请求 308 跳转到 HTTPS 的处理器
+// - /health 仍直接交给 fallback 处理，便于负载均衡与 K8s 探针继续使用 HTTP 探测
+// - httpsAddr 为 HTTPS 监听地址，端口不是 443 时保留在跳转地址中
+func RedirectHandler(httpsAddr string, fallback http.Handler) http.Handler {
+	_, httpsPort, _ := net.SplitHostPort(httpsAddr)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/health" {
+			fallback.ServeHTTP(w, r)
+			return
+		}
+		host := r.Host
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+		if httpsPort != "" && httpsPort != "443" {
+			host = net.JoinHostPort(host, httpsPort)
+		}
+		target := "https://" + host + r.URL.RequestURI()
+		http.Redirect(w, r, target, http.StatusPermanentRedirect)
+	})
+}
@@ -0,0 +1,120 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"sync"
+
+	"github.com/Xushengqwer/gateway/internal/apikey"
+	"github.com/Xushengqwer/gateway/internal/auth"
+	"github.com/Xushengqwer/gateway/internal/config"
+	"github.com/Xushengqwer/go-common/models/enums"
+	"github.com/Xushengqwer/go-common/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+// APIKeyAuthenticator 校验请求携带的 API Key，并按 Key 的套餐限流
+type APIKeyAuthenticator struct {
+	store      *apikey.Store
+	header     string
+	queryParam string
+	plans      map[string]config.APIKeyPlan
+
+	limiters sync.Map // "<keyID>/<plan>" -> *RateLimiter
+}
+
+// NewAPIKeyAuthenticator 创建 API Key 认证器
+func NewAPIKeyAuthenticator(cfg *config.APIKeyConfig, store *apikey.Store) *APIKeyAuthenticator {
+	return &APIKeyAuthenticator{
+		store:      store,
+		header:     cfg.HeaderName(),
+		queryParam: cfg.QueryParam,
+		plans:      cfg.Plans,
+	}
+}
+
+// Scheme 实现 auth.Authenticator
+func (a *APIKeyAuthenticator) Scheme() string { return config.AuthSchemeAPIKey }
+
+// Authenticate 实现 auth.Authenticator
+// - Key 无效 401；Key 不允许访问当前服务 403；超出套餐限流 429
+// - 成功时从转发给上游的请求中移除 Key，并通过 X-API-Key-ID 告知上游所用的 Key
+func (a *APIKeyAuthenticator) Authenticate(c *gin.Context) (*auth.Principal, error) {
+	plaintext := a.extract(c)
+	if plaintext == "" {
+		return nil, auth.Missing("缺少 API Key")
+	}
+	key, err := a.store.Verify(plaintext)
+	if err != nil {
+		return nil, auth.Unauthorized("API Key 无效")
+	}
+	if svcVal, ok := c.Get(ServiceConfigKey); ok {
+		if svc, ok := svcVal.(*config.ServiceConfig); ok && !key.AllowsService(svc.Name) {
+			return nil, auth.Forbidden("API Key 无权访问该服务")
+		}
+	}
+	if err := a.allow(key); err != nil {
+		if errors.Is(err, errRateLimited) {
+			c.Header("Retry-After", formatFloatToString(a.plans[key.Plan].RefillInterval.Seconds(), 1))
+			return nil, &auth.Error{Status: http.StatusTooManyRequests, Code: response.ErrCodeClientRateLimitExceeded, Message: "请求频率超出 API Key 套餐限制，请稍后重试"}
+		}
+		return nil, auth.Forbidden(err.Error())
+	}
+
+	a.strip(c)
+	return &auth.Principal{
+		ID:      key.Owner,
+		Role:    key.Role,
+		Status:  enums.StatusActive,
+		Scopes:  key.Scopes,
+		Headers: map[string]string{"X-API-Key-ID": key.ID},
+	}, nil
+}
+
+// errRateLimited 表示 Key 超出套餐限流
+var errRateLimited = errors.New("rate limited")
+
+// allow 按 Key 的套餐消耗一个令牌；未设置套餐的 Key 不限流，引用了未配置套餐的 Key 一律拒绝
+func (a *APIKeyAuthenticator) allow(key apikey.Key) error {
+	if key.Plan == "" {
+		return nil
+	}
+	plan, ok := a.plans[key.Plan]
+	if !ok {
+		return errors.New("API Key 的限流套餐未配置")
+	}
+	id := key.ID + "/" + key.Plan
+	val, ok := a.limiters.Load(id)
+	if !ok {
+		val, _ = a.limiters.LoadOrStore(id, NewRateLimiter(plan.Capacity, plan.RefillInterval))
+	}
+	if !val.(*RateLimiter).Allow() {
+		return errRateLimited
+	}
+	return nil
+}
+
+// extract 依次从请求头与查询参数读取 API Key
+func (a *APIKeyAuthenticator) extract(c *gin.Context) string {
+	if v := c.GetHeader(a.header); v != "" {
+		return v
+	}
+	if a.queryParam != "" {
+		return c.Query(a.queryParam)
+	}
+	return ""
+}
+
+// strip 从转发给上游的请求中移除 API Key
+func (a *APIKeyAuthenticator) strip(c *gin.Context) {
+	c.Request.Header.Del(a.header)
+	if a.queryParam == "" {
+		return
+	}
+	query := c.Request.URL.Query()
+	if query.Has(a.queryParam) {
+		query.Del(a.queryParam)
+		c.Request.URL.RawQuery = query.Encode()
+	}
+}
@@ -3,8 +3,9 @@ package middleware
 import (
 	"errors"
 	"fmt"
+	"github.com/Xushengqwer/gateway/internal/auth"
+	"github.com/Xushengqwer/gateway/internal/config"
 	"github.com/Xushengqwer/gateway/internal/core"
-	"github.com/Xushengqwer/go-common/constants"
 	"github.com/Xushengqwer/go-common/models/enums"
 
 	"github.com/Xushengqwer/go-common/response"
@@ -19,89 +20,88 @@ import (
 // AuthMiddleware 定义认证中间件，用于验证请求中的访问令牌
 // - 输入: jwtUtil JWT 工具实例，用于解析令牌
 // - 输出: gin.HandlerFunc 中间件函数
+// - 只接受 JWT；需要按路由组合多种认证方式时使用 auth.Chain
 func AuthMiddleware(jwtUtil core.JWTUtilityInterface) gin.HandlerFunc {
+	chain := auth.NewChain(NewJWTAuthenticator(jwtUtil))
+	schemes := []string{config.AuthSchemeJWT}
 	return func(c *gin.Context) {
-		// 1. 获取请求头中的 Authorization 字段
-		// - 期望格式: "Bearer <token>"
-		// - 如果缺失，返回未授权错误
-		authorizationHeader := c.GetHeader("Authorization")
-		if authorizationHeader == "" {
-			response.RespondError(c, http.StatusUnauthorized, response.ErrCodeClientUnauthorized, "缺少或不正确的令牌")
-			c.Abort()
-			return
+		if chain.Authenticate(c, schemes) {
+			c.Next()
 		}
+	}
+}
 
-		// 2. 解析 Bearer Token
-		// - 从 Authorization 头中提取令牌字符串
-		// - 如果格式错误，返回未授权错误
-		accessToken, err := parseBearerToken(authorizationHeader)
-		if err != nil {
-			response.RespondError(c, http.StatusUnauthorized, response.ErrCodeClientUnauthorized, "令牌格式错误")
-			c.Abort()
-			return
-		}
+// JWTAuthenticator 是 "jwt" 认证方式，校验 Authorization: Bearer <access token>
+type JWTAuthenticator struct {
+	jwtUtil core.JWTUtilityInterface
+}
 
-		// 3. 解析并验证访问令牌
-		// - 使用 JWTUtilityInterface 解析令牌，获取声明
-		// - 如果解析失败，根据错误类型返回相应响应
-		claims, parseErr := jwtUtil.ParseAccessToken(accessToken)
-		if parseErr != nil {
-			fmt.Printf("解析认证令牌错误: %v\n", parseErr)
-
-			// 4. 检查具体错误类型（使用 v5 的错误常量）
-			// - 根据错误类型返回不同的错误码和消息
-			switch {
-			case errors.Is(parseErr, jwt.ErrTokenExpired):
-				// - 令牌过期错误
-				response.RespondError(c, http.StatusUnauthorized, response.ErrCodeClientAccessTokenExpired, "访问令牌已过期")
-			case errors.Is(parseErr, jwt.ErrTokenMalformed), errors.Is(parseErr, jwt.ErrTokenSignatureInvalid), errors.Is(parseErr, jwt.ErrTokenInvalidClaims):
-				// - 令牌无效（格式错误、签名无效或声明无效）
-				response.RespondError(c, http.StatusUnauthorized, response.ErrCodeClientUnauthorized, "无效令牌")
-			default:
-				// - 其他未知错误
-				response.RespondError(c, http.StatusUnauthorized, response.ErrCodeClientUnauthorized, "令牌验证失败")
-			}
-			c.Abort()
-			return
-		}
+// NewJWTAuthenticator 创建 JWT 认证方式
+func NewJWTAuthenticator(jwtUtil core.JWTUtilityInterface) *JWTAuthenticator {
+	return &JWTAuthenticator{jwtUtil: jwtUtil}
+}
 
-		// 5. 验证平台是否匹配
-		// - 根据请求路径或 header 判断预期平台，与令牌中的平台进行比较
-		// - 如果不匹配，返回禁止访问错误
-		expectedPlatform, err := getExpectedPlatform(c.Request)
-		if err != nil {
-			response.RespondError(c, http.StatusBadRequest, response.ErrCodeClientInvalidInput, err.Error())
-			c.Abort()
-			return
-		}
-		if string(claims.Platform) != expectedPlatform {
-			response.RespondError(c, http.StatusForbidden, response.ErrCodeClientForbidden, "平台不匹配")
-			c.Abort()
-			return
-		}
+// Scheme 实现 auth.Authenticator
+func (a *JWTAuthenticator) Scheme() string { return config.AuthSchemeJWT }
 
-		// 6. 检查用户状态
-		// - 如果用户被拉黑，禁止访问
-		// - 返回禁止访问错误
-		if claims.Status == enums.StatusBlacklisted {
-			response.RespondError(c, http.StatusForbidden, response.ErrCodeClientForbidden, "用户已被拉黑")
-			c.Abort()
-			return
+// Authenticate 实现 auth.Authenticator
+func (a *JWTAuthenticator) Authenticate(c *gin.Context) (*auth.Principal, error) {
+	// 1. 获取请求头中的 Authorization 字段
+	// - 期望格式: "Bearer <token>"
+	// - 缺失或是其他认证方式（如 Basic）时视为未携带 JWT，交给下一种认证方式
+	authorizationHeader := c.GetHeader("Authorization")
+	if len(authorizationHeader) < 7 || !strings.EqualFold(authorizationHeader[:7], "bearer ") {
+		return nil, auth.Missing("缺少或不正确的令牌")
+	}
+
+	// 2. 解析 Bearer Token
+	// - 从 Authorization 头中提取令牌字符串
+	accessToken, err := parseBearerToken(authorizationHeader)
+	if err != nil || accessToken == "" {
+		return nil, auth.Unauthorized("令牌格式错误")
+	}
+
+	// 3. 解析并验证访问令牌
+	// - 使用 JWTUtilityInterface 解析令牌，获取声明
+	// - 如果解析失败，根据错误类型返回相应错误
+	claims, parseErr := a.jwtUtil.ParseAccessToken(accessToken)
+	if parseErr != nil {
+		fmt.Printf("解析认证令牌错误: %v\n", parseErr)
+
+		// 4. 检查具体错误类型（使用 v5 的错误常量）
+		switch {
+		case errors.Is(parseErr, jwt.ErrTokenExpired):
+			// - 令牌过期错误
+			return nil, &auth.Error{Status: http.StatusUnauthorized, Code: response.ErrCodeClientAccessTokenExpired, Message: "访问令牌已过期"}
+		case errors.Is(parseErr, jwt.ErrTokenMalformed), errors.Is(parseErr, jwt.ErrTokenSignatureInvalid), errors.Is(parseErr, jwt.ErrTokenInvalidClaims):
+			// - 令牌无效（格式错误、签名无效或声明无效）
+			return nil, auth.Unauthorized("无效令牌")
+		default:
+			// - 其他未知错误
+			return nil, auth.Unauthorized("令牌验证失败")
 		}
+	}
+
+	// 5. 验证平台是否匹配
+	// - 根据请求路径或 header 判断预期平台，与令牌中的平台进行比较
+	if err := CheckPlatform(c.Request, string(claims.Platform)); err != nil {
+		return nil, err
+	}
 
-		// 7. 令牌有效，将用户信息存入上下文
-		// - 将 UserID、Role、Status 存入 gin.Context，供后续处理使用
-		// - 继续处理请求
-		// 将用户信息添加到 HTTP 头
-		// 将用户状态和角色存入上下文
-		c.Set(string(constants.StatusKey), claims.Status) //
-		c.Set(string(constants.RoleKey), claims.Role)     //
-		c.Request.Header.Set("X-User-ID", claims.UserID)
-		c.Request.Header.Set("X-User-Role", claims.Role.String())
-		c.Request.Header.Set("X-User-Status", claims.Status.String())
-		c.Request.Header.Set("X-Platform", string(claims.Platform))
-		c.Next()
+	// 6. 检查用户状态
+	// - 如果用户被拉黑，禁止访问
+	if claims.Status == enums.StatusBlacklisted {
+		return nil, auth.Forbidden("用户已被拉黑")
 	}
+
+	// 7. 令牌有效，返回身份；由 auth.SetPrincipal 写入上下文与 X-User-* 请求头
+	return &auth.Principal{
+		ID:       claims.UserID,
+		Role:     claims.Role,
+		Status:   claims.Status,
+		Platform: string(claims.Platform),
+		Scopes:   claims.Scopes(),
+	}, nil
 }
 
 // parseBearerToken 从 Authorization 头中提取令牌
@@ -117,6 +117,20 @@ func parseBearerToken(authHeader string) (string, error) {
 	return parts[1], nil
 }
 
+// CheckPlatform 校验令牌中的平台与请求的预期平台是否一致
+// - 无法确定预期平台时返回 400，平台不匹配时返回 403
+// - JWT 认证与离线策略测试（gateway test-policy）共用
+func CheckPlatform(r *http.Request, platform string) *auth.Error {
+	expectedPlatform, err := getExpectedPlatform(r)
+	if err != nil {
+		return &auth.Error{Status: http.StatusBadRequest, Code: response.ErrCodeClientInvalidInput, Message: err.Error()}
+	}
+	if platform != expectedPlatform {
+		return auth.Forbidden("平台不匹配")
+	}
+	return nil
+}
+
 // getExpectedPlatform 根据请求头或路径判断预期平台
 // - 输入: r HTTP 请求对象
 // - 输出: 预期平台字符串
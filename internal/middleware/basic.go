@@ -0,0 +1,60 @@
+package middleware
+
+import (
+	"github.com/Xushengqwer/gateway/internal/auth"
+	"github.com/Xushengqwer/gateway/internal/config"
+	"github.com/Xushengqwer/go-common/models/enums"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// BasicAuthenticator 是 "basic" 认证方式，按 basicAuth.users 校验 Authorization: Basic
+type BasicAuthenticator struct {
+	users map[string]config.BasicAuthUser
+	// dummyHash 在用户名不存在时参与比较，使两条路径耗时相同，无法通过响应时间枚举用户名
+	dummyHash []byte
+}
+
+// NewBasicAuthenticator 创建 Basic 认证方式
+// - dummyHash 的 cost 取已配置用户中的最大值，与最慢的真实比较耗时一致
+func NewBasicAuthenticator(cfg *config.BasicAuthConfig) *BasicAuthenticator {
+	a := &BasicAuthenticator{users: make(map[string]config.BasicAuthUser, len(cfg.Users))}
+	cost := bcrypt.DefaultCost
+	for _, u := range cfg.Users {
+		a.users[u.Username] = u
+		if c, err := bcrypt.Cost([]byte(u.PasswordHash)); err == nil && c > cost {
+			cost = c
+		}
+	}
+	a.dummyHash, _ = bcrypt.GenerateFromPassword([]byte("gateway-basic-auth-unknown-user"), cost)
+	return a
+}
+
+// Scheme 实现 auth.Authenticator
+func (a *BasicAuthenticator) Scheme() string { return config.AuthSchemeBasic }
+
+// Authenticate 实现 auth.Authenticator
+// - 成功后移除 Authorization 头，密码不会转发给上游
+func (a *BasicAuthenticator) Authenticate(c *gin.Context) (*auth.Principal, error) {
+	username, password, ok := c.Request.BasicAuth()
+	if !ok {
+		return nil, auth.Missing("缺少 Basic 认证凭证")
+	}
+	user, found := a.users[username]
+	hash := a.dummyHash
+	if found {
+		hash = []byte(user.PasswordHash)
+	}
+	if err := bcrypt.CompareHashAndPassword(hash, []byte(password)); !found || err != nil {
+		c.Header("WWW-Authenticate", `Basic realm="gateway", charset="UTF-8"`)
+		return nil, auth.Unauthorized("用户名或密码错误")
+	}
+	c.Request.Header.Del("Authorization")
+
+	userID := user.UserID
+	if userID == "" {
+		userID = user.Username
+	}
+	return &auth.Principal{ID: userID, Role: user.Role, Status: enums.StatusActive}, nil
+}
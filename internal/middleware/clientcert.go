@@ -0,0 +1,77 @@
+package middleware
+
+import (
+	"crypto/x509"
+
+	"github.com/Xushengqwer/gateway/internal/auth"
+	"github.com/Xushengqwer/gateway/internal/config"
+	"github.com/Xushengqwer/go-common/models/enums"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ClientCertAuthenticator 把经 HTTPS 监听校验过的客户端证书映射为网关身份
+// - 证书链的校验由 tls.clientCAFile 完成，这里只做主题 / SAN 到用户 ID 与角色的映射
+type ClientCertAuthenticator struct {
+	identities []config.ClientCertIdentity
+}
+
+// NewClientCertAuthenticator 创建客户端证书认证器，cfg 为 nil 时不会匹配任何证书
+func NewClientCertAuthenticator(cfg *config.ClientCertAuthConfig) *ClientCertAuthenticator {
+	a := &ClientCertAuthenticator{}
+	if cfg != nil {
+		a.identities = cfg.Identities
+	}
+	return a
+}
+
+// Scheme 实现 auth.Authenticator
+func (a *ClientCertAuthenticator) Scheme() string { return config.AuthSchemeMTLS }
+
+// Authenticate 实现 auth.Authenticator
+// - 未出示证书、证书未经校验或没有匹配的身份时视为未携带凭证，交给下一种认证方式
+func (a *ClientCertAuthenticator) Authenticate(c *gin.Context) (*auth.Principal, error) {
+	state := c.Request.TLS
+	if state == nil || len(state.VerifiedChains) == 0 || len(state.PeerCertificates) == 0 {
+		return nil, auth.Missing("缺少有效的客户端证书")
+	}
+	id, ok := a.match(state.PeerCertificates[0])
+	if !ok {
+		return nil, auth.Missing("缺少有效的客户端证书")
+	}
+	return &auth.Principal{ID: id.UserID, Role: id.Role, Status: enums.StatusActive}, nil
+}
+
+// match 返回第一条与证书匹配的身份
+func (a *ClientCertAuthenticator) match(cert *x509.Certificate) (config.ClientCertIdentity, bool) {
+	for _, id := range a.identities {
+		if id.CommonName != "" && id.CommonName != cert.Subject.CommonName {
+			continue
+		}
+		if id.SAN != "" && !hasSAN(cert, id.SAN) {
+			continue
+		}
+		return id, true
+	}
+	return config.ClientCertIdentity{}, false
+}
+
+// hasSAN 判断证书的 DNS / URI / Email SAN 中是否包含 san
+func hasSAN(cert *x509.Certificate, san string) bool {
+	for _, name := range cert.DNSNames {
+		if name == san {
+			return true
+		}
+	}
+	for _, uri := range cert.URIs {
+		if uri.String() == san {
+			return true
+		}
+	}
+	for _, email := range cert.EmailAddresses {
+		if email == san {
+			return true
+		}
+	}
+	return false
+}
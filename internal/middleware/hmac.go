@@ -0,0 +1,194 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/Xushengqwer/gateway/internal/auth"
+	"github.com/Xushengqwer/gateway/internal/config"
+	"github.com/Xushengqwer/gateway/internal/policy"
+	"github.com/Xushengqwer/go-common/models/enums"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultHMACMaxSkew      = 5 * time.Minute
+	defaultHMACMaxBodyBytes = 10 << 20
+	maxHMACNonceLength      = 128
+)
+
+// HMACAuthenticator 是 "hmac" 认证方式，校验 GW-HMAC-SHA256 请求签名（协议见 auth.HMACStringToSign）
+type HMACAuthenticator struct {
+	keys      map[string]config.HMACKey
+	maxSkew   time.Duration
+	maxBody   int64
+	globalMax int64 // 全局 limits.maxBodyBytes，与服务、路由级限制合并后约束签名时读取的请求体
+	nonces    *nonceCache
+}
+
+// NewHMACAuthenticator 创建 HMAC 签名认证方式，globalMaxBody 为全局 limits.maxBodyBytes（0 表示不限制）
+func NewHMACAuthenticator(cfg *config.HMACAuthConfig, globalMaxBody int64) *HMACAuthenticator {
+	a := &HMACAuthenticator{
+		keys:      make(map[string]config.HMACKey, len(cfg.Keys)),
+		maxSkew:   cfg.MaxSkew,
+		maxBody:   cfg.MaxBodyBytes,
+		globalMax: globalMaxBody,
+	}
+	if a.maxSkew <= 0 {
+		a.maxSkew = defaultHMACMaxSkew
+	}
+	if a.maxBody <= 0 {
+		a.maxBody = defaultHMACMaxBodyBytes
+	}
+	for _, k := range cfg.Keys {
+		a.keys[k.KeyID] = k
+	}
+	// 时间戳可在 now±maxSkew 内被接受，nonce 需要记住整个窗口
+	a.nonces = newNonceCache(2 * a.maxSkew)
+	return a
+}
+
+// Scheme 实现 auth.Authenticator
+func (a *HMACAuthenticator) Scheme() string { return config.AuthSchemeHMAC }
+
+// Authenticate 实现 auth.Authenticator
+// - 时间戳超出 maxSkew、签名不符或 nonce 重复时返回 401
+// - 请求体超过 hmac.maxBodyBytes 或路由合并后的 maxBodyBytes（取较小者）时返回 413，超限的请求体不会被完整读取
+// - 读取的请求体会放回请求中，继续转发给上游
+func (a *HMACAuthenticator) Authenticate(c *gin.Context) (*auth.Principal, error) {
+	cred, ok := auth.ParseHMACAuthorization(c.GetHeader("Authorization"))
+	if !ok {
+		return nil, auth.Missing("缺少请求签名")
+	}
+	if cred.KeyID == "" || cred.Signature == "" || !slices.Contains(cred.SignedHeaders, "host") {
+		return nil, auth.Unauthorized("签名参数不完整 (需要 KeyId、Signature，且 SignedHeaders 包含 host)")
+	}
+	key, found := a.keys[cred.KeyID]
+	if !found {
+		return nil, auth.Unauthorized("签名密钥无效")
+	}
+
+	timestamp := c.GetHeader(auth.HMACTimestampHeader)
+	ts, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return nil, auth.Unauthorized("签名时间戳无效")
+	}
+	now := time.Now()
+	if skew := now.Sub(time.Unix(ts, 0)); skew > a.maxSkew || skew < -a.maxSkew {
+		return nil, auth.Unauthorized("签名已过期，请检查客户端时钟")
+	}
+	nonce := c.GetHeader(auth.HMACNonceHeader)
+	if nonce == "" || len(nonce) > maxHMACNonceLength {
+		return nil, auth.Unauthorized("缺少或无效的 nonce")
+	}
+
+	bodyHash, err := a.hashBody(c.Request, a.bodyLimit(c))
+	if err != nil {
+		return nil, err
+	}
+	stringToSign := auth.HMACStringToSign(c.Request, cred.SignedHeaders, timestamp, nonce, bodyHash)
+	if !auth.HMACVerify(key.Secret, stringToSign, cred.Signature) {
+		return nil, auth.Unauthorized("签名无效")
+	}
+	// 签名通过后才登记 nonce，伪造的请求无法占满 nonce 记录
+	if !a.nonces.add(cred.KeyID+":"+nonce, now) {
+		return nil, auth.Unauthorized("重复的请求 (nonce 已使用)")
+	}
+
+	userID := key.UserID
+	if userID == "" {
+		userID = key.KeyID
+	}
+	return &auth.Principal{
+		ID:      userID,
+		Role:    key.Role,
+		Status:  enums.StatusActive,
+		Scopes:  key.Scopes,
+		Headers: map[string]string{"X-HMAC-Key-ID": key.KeyID},
+	}, nil
+}
+
+// bodyLimit 返回计算签名时可读取的请求体上限：hmac.maxBodyBytes 与当前服务、路由合并后的 maxBodyBytes 取较小者
+// - 认证发生在转发阶段的请求体检查之前，不在这里收紧上限的话，超过路由限制的请求体会先被完整读入内存
+func (a *HMACAuthenticator) bodyLimit(c *gin.Context) int64 {
+	var svcLimits, routeLimits *config.BodyLimit
+	if v, ok := c.Get(ServiceConfigKey); ok {
+		if svc, ok := v.(*config.ServiceConfig); ok && svc != nil {
+			svcLimits = svc.Limits
+		}
+	}
+	if v, ok := c.Get(PolicyResolutionKey); ok {
+		if res, ok := v.(policy.Resolution); ok && res.Route != nil {
+			routeLimits = res.Route.Limits
+		}
+	}
+	limit := a.maxBody
+	if merged := config.MergeBodyLimits(a.globalMax, svcLimits, routeLimits); merged.MaxBodyBytes > 0 && merged.MaxBodyBytes < limit {
+		limit = merged.MaxBodyBytes
+	}
+	return limit
+}
+
+// hashBody 读取至多 limit 字节的请求体计算 SHA-256，并把请求体放回请求
+// - Content-Length 已超过 limit 时不读取请求体，直接返回 413
+func (a *HMACAuthenticator) hashBody(req *http.Request, limit int64) (string, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		sum := sha256.Sum256(nil)
+		return hex.EncodeToString(sum[:]), nil
+	}
+	tooLarge := &auth.Error{Status: http.StatusRequestEntityTooLarge, Code: ErrCodeClientBodyTooLarge, Message: "签名请求的请求体过大"}
+	if req.ContentLength > limit {
+		return "", tooLarge
+	}
+	data, err := io.ReadAll(io.LimitReader(req.Body, limit+1))
+	req.Body.Close()
+	if err != nil {
+		return "", auth.Unauthorized("读取请求体失败")
+	}
+	if int64(len(data)) > limit {
+		return "", tooLarge
+	}
+	req.Body = io.NopCloser(bytes.NewReader(data))
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// nonceCache 记录窗口期内已使用的 nonce
+type nonceCache struct {
+	ttl time.Duration
+
+	mu        sync.Mutex
+	seen      map[string]time.Time // nonce -> 过期时间
+	nextSweep time.Time
+}
+
+func newNonceCache(ttl time.Duration) *nonceCache {
+	return &nonceCache{ttl: ttl, seen: make(map[string]time.Time)}
+}
+
+// add 登记 nonce，nonce 在有效期内已存在时返回 false
+func (n *nonceCache) add(nonce string, now time.Time) bool {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if now.After(n.nextSweep) {
+		for k, exp := range n.seen {
+			if now.After(exp) {
+				delete(n.seen, k)
+			}
+		}
+		n.nextSweep = now.Add(time.Minute)
+	}
+	if exp, ok := n.seen[nonce]; ok && now.Before(exp) {
+		return false
+	}
+	n.seen[nonce] = now.Add(n.ttl)
+	return true
+}
@@ -0,0 +1,27 @@
+package middleware
+
+import (
+	"strconv"
+
+	"github.com/Xushengqwer/gateway/internal/config"
+
+	"github.com/gin-gonic/gin"
+)
+
+// HSTSMiddleware 为经由 HTTPS 到达的请求添加 Strict-Transport-Security 响应头
+// - 明文 HTTP 请求上的 HSTS 头会被浏览器忽略，因此只在 TLS 连接上设置
+func HSTSMiddleware(cfg config.HSTSConfig) gin.HandlerFunc {
+	value := "max-age=" + strconv.Itoa(int(cfg.MaxAge.Seconds()))
+	if cfg.IncludeSubDomains {
+		value += "; includeSubDomains"
+	}
+	if cfg.Preload {
+		value += "; preload"
+	}
+	return func(c *gin.Context) {
+		if c.Request.TLS != nil {
+			c.Header("Strict-Transport-Security", value)
+		}
+		c.Next()
+	}
+}
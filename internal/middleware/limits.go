@@ -0,0 +1,44 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/Xushengqwer/gateway/internal/config"
+	sharedCore "github.com/Xushengqwer/go-common/core"
+	"github.com/Xushengqwer/go-common/response"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// 网关自定义的请求限制错误码（go-common 未定义，沿用 HTTP 状态码 + 两位序号的格式）
+const (
+	ErrCodeClientBodyTooLarge       = 41301 // 请求体超过大小限制
+	ErrCodeClientUnsupportedMedia   = 41501 // 请求体类型或编码不被接受
+	ErrCodeClientHeaderFieldsTooBig = 43101 // 请求头过大或字段过多
+)
+
+// HeaderLimitMiddleware 限制请求头的总大小与字段数量，超限时返回 431
+// - http.Server.MaxHeaderBytes 只作为兜底（超出时由标准库直接断开），这里负责返回统一格式的错误
+func HeaderLimitMiddleware(logger *sharedCore.ZapLogger, cfg config.LimitsConfig) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		count, size := 0, len(c.Request.Method)+len(c.Request.RequestURI)+len(c.Request.Proto)+4
+		for name, values := range c.Request.Header {
+			for _, v := range values {
+				count++
+				size += len(name) + len(v) + 4 // ": " 与 CRLF
+			}
+		}
+		if (cfg.MaxHeaderCount > 0 && count > cfg.MaxHeaderCount) || (cfg.MaxHeaderBytes > 0 && size > cfg.MaxHeaderBytes) {
+			logger.Warn("请求头超出限制",
+				zap.Int("headerCount", count),
+				zap.Int("headerBytes", size),
+				zap.String("path", c.Request.URL.Path),
+				zap.String("clientIP", c.ClientIP()))
+			response.RespondError(c, http.StatusRequestHeaderFieldsTooLarge, ErrCodeClientHeaderFieldsTooBig, "请求头过大或字段过多")
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
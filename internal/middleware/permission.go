@@ -1,173 +1,83 @@
 package middleware
 
 import (
+	"github.com/Xushengqwer/gateway/internal/auth"
 	"github.com/Xushengqwer/gateway/internal/config"
-	"github.com/Xushengqwer/go-common/constants"
-	"github.com/Xushengqwer/go-common/models/enums"
+	"github.com/Xushengqwer/gateway/internal/policy"
 	"github.com/Xushengqwer/go-common/response"
 	"net/http"
-	"strings"
 
 	"github.com/gin-gonic/gin"
 )
 
-// MatchRoute 检查请求是否匹配给定的路由规则 (已导出)
-func MatchRoute(route config.RouteConfig, requestPath, requestMethod string) (bool, int) {
-	// 1. 检查 HTTP 方法
-	if len(route.Methods) > 0 {
-		methodMatch := false
-		for _, m := range route.Methods {
-			if strings.EqualFold(m, requestMethod) { // 使用不区分大小写的比较
-				methodMatch = true
-				break
-			}
-		}
-		if !methodMatch {
-			return false, 0 // 方法不匹配
-		}
-	}
+// ServiceConfigKey 是代理处理器在 gin.Context 中存放当前命中服务配置 (*config.ServiceConfig) 的键
+// - 多个服务共享同一前缀时，权限中间件依赖它定位到真正被选中的服务
+const ServiceConfigKey = "gatewayServiceConfig"
 
-	// 2. 检查路径
-	routeSegments := strings.Split(strings.Trim(route.Path, "/"), "/")
-	requestSegments := strings.Split(strings.Trim(requestPath, "/"), "/")
+// SkipTimeoutKey 是 go-common RequestTimeoutMiddleware 约定的“跳过全局超时”标志键
+// - 配置了自身整体超时的服务通过它绕开全局 requestTimeout，由代理处理器自行控制超时
+const SkipTimeoutKey = "skipTimeout"
 
-	// 处理根路径 "/" 的情况 (包括 / vs "")
-	isRouteRoot := route.Path == "/" || (len(routeSegments) == 1 && routeSegments[0] == "")
-	isRequestRoot := requestPath == "/" || (len(requestSegments) == 1 && requestSegments[0] == "")
+// PolicyResolutionKey 是代理处理器在 gin.Context 中存放本次请求策略匹配结果 (policy.Resolution) 的键
+// - 设置后权限中间件直接使用该结果，不再重复匹配
+const PolicyResolutionKey = "gatewayPolicyResolution"
 
-	if isRouteRoot && isRequestRoot {
-		return true, 1 // 都代表根路径，匹配
-	}
+// PolicyDecisionKey 是权限中间件在 gin.Context 中存放授权判断结果 (policy.Decision) 的键，供日志与审计使用
+const PolicyDecisionKey = "gatewayPolicyDecision"
 
-	if len(routeSegments) != len(requestSegments) {
-		return false, 0 // 段数必须相同
+// PermissionMiddleware 定义权限中间件 (重构版)
+// - 判断逻辑统一由 policy.Engine 完成：deny 规则 > publicPaths > routes > defaultPolicy
+// - 角色表已在启动时由 Validate 校验
+func PermissionMiddleware(cfg *config.GatewayConfig) gin.HandlerFunc {
+	roles, err := cfg.RoleHierarchy()
+	if err != nil {
+		roles, _ = config.NewRoleHierarchy(config.DefaultRoles)
 	}
-
-	matchScore := 0
-	for i, segment := range routeSegments {
-		if strings.HasPrefix(segment, ":") || strings.HasPrefix(segment, "{") {
-			// 如果请求段为空，则参数不匹配（除非我们允许空参数，但这里不允许）
-			if requestSegments[i] == "" {
-				return false, 0
-			}
-			matchScore++ // 参数匹配，分数较低
-		} else if segment == requestSegments[i] {
-			matchScore += 2 // 静态匹配，分数较高
-		} else {
-			return false, 0 // 任何段不匹配则整个路径不匹配
-		}
+	engines := make(map[string]*policy.Engine, len(cfg.Services))
+	for i := range cfg.Services {
+		engines[cfg.Services[i].Name] = policy.New(&cfg.Services[i], roles)
 	}
+	services := policy.NewServiceIndex(cfg.ServiceGroups())
 
-	return true, matchScore // 返回匹配成功和得分
-}
-
-// FindBestMatchingRoute 找到最匹配的路由规则 (已导出)
-func FindBestMatchingRoute(routes []config.RouteConfig, relativePath, method string) (*config.RouteConfig, bool) {
-	var bestMatch *config.RouteConfig
-	bestScore := -1 // 初始化为 -1，确保任何匹配都优于它
-
-	for i := range routes {
-		route := routes[i]                                        // 使用索引来获取值，避免闭包问题
-		matches, score := MatchRoute(route, relativePath, method) // 使用导出的函数
-		if matches {
-			currentSegments := strings.Split(strings.Trim(route.Path, "/"), "/")
-			currentLength := len(currentSegments)
-
-			bestLength := 0
-			if bestMatch != nil {
-				bestSegments := strings.Split(strings.Trim(bestMatch.Path, "/"), "/")
-				bestLength = len(bestSegments)
-			}
-
-			if score > bestScore || (score == bestScore && currentLength > bestLength) {
-				bestScore = score
-				bestMatch = &route
-			}
-		}
-	}
-
-	return bestMatch, bestMatch != nil
-}
-
-// PermissionMiddleware 定义权限中间件 (重构版)
-func PermissionMiddleware(cfg *config.GatewayConfig) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		// --- 获取用户状态和角色 ---
-		statusVal, exists := c.Get(string(constants.StatusKey))
-		if !exists {
-			response.RespondError(c, http.StatusForbidden, response.ErrCodeClientForbidden, "状态获取失败")
-			c.Abort()
-			return
+		// --- 获取认证身份（任一认证方式产出的 auth.Principal） ---
+		principal, _ := auth.PrincipalFrom(c)
+
+		// --- 定位服务：优先使用代理处理器选中的服务，否则按最长前缀查找（取组内首个服务） ---
+		var engine *policy.Engine
+		if svcVal, ok := c.Get(ServiceConfigKey); ok {
+			if svc, ok := svcVal.(*config.ServiceConfig); ok {
+				engine = engines[svc.Name]
+				if engine == nil {
+					engine = policy.New(svc, roles)
+				}
+			}
 		}
-		status, ok := statusVal.(enums.UserStatus)
-		if !ok {
-			response.RespondError(c, http.StatusForbidden, response.ErrCodeClientForbidden, "状态信息无效")
-			c.Abort()
-			return
+		if engine == nil {
+			if group, ok := services.Lookup(c.Request.URL.Path); ok {
+				engine = engines[group.Services[0].Name]
+			}
 		}
-
-		if status == enums.StatusBlacklisted {
-			response.RespondError(c, http.StatusForbidden, response.ErrCodeClientForbidden, "用户已被拉黑")
+		if engine == nil {
+			response.RespondError(c, http.StatusNotFound, response.ErrCodeClientResourceNotFound, "服务未找到 (Perm)")
 			c.Abort()
 			return
 		}
 
-		roleValue, exists := c.Get(string(constants.RoleKey))
-		if !exists {
-			response.RespondError(c, http.StatusForbidden, response.ErrCodeClientForbidden, "权限不足 (无法获取角色)")
-			c.Abort()
-			return
+		var res policy.Resolution
+		if resVal, ok := c.Get(PolicyResolutionKey); ok {
+			res, _ = resVal.(policy.Resolution)
+		} else {
+			res = engine.Resolve(policy.RelativePath(engine.Service().Prefix, c.Request.URL.Path), c.Request.Method)
 		}
 
-		role, ok := roleValue.(enums.UserRole)
-		if !ok {
-			response.RespondError(c, http.StatusForbidden, response.ErrCodeClientForbidden, "角色信息无效")
+		decision := engine.Authorize(res, principal)
+		c.Set(PolicyDecisionKey, decision)
+		if !decision.Allowed {
+			response.RespondError(c, decision.Status, decision.Code, decision.Message)
 			c.Abort()
 			return
 		}
-
-		// --- 匹配逻辑 ---
-		path := c.Request.URL.Path
-		method := c.Request.Method
-
-		for _, svc := range cfg.Services {
-			if strings.HasPrefix(path, svc.Prefix) {
-				relativePath := strings.TrimPrefix(path, svc.Prefix)
-				if !strings.HasPrefix(relativePath, "/") && relativePath != "" {
-					relativePath = "/" + relativePath
-				} else if relativePath == "" {
-					relativePath = "/"
-				}
-
-				bestRoute, found := FindBestMatchingRoute(svc.Routes, relativePath, method) // 使用导出的函数
-
-				if !found {
-					// 理论上不应发生，因为 createProxyHandler 已确保这是私有路由
-					response.RespondError(c, http.StatusForbidden, response.ErrCodeClientForbidden, "无权访问该路径或路径未定义 (Perm)")
-					c.Abort()
-					return
-				}
-
-				hasPermission := false
-				for _, allowedRole := range bestRoute.AllowedRoles {
-					if role == allowedRole {
-						hasPermission = true
-						break
-					}
-				}
-
-				if hasPermission {
-					c.Next()
-					return
-				} else {
-					response.RespondError(c, http.StatusForbidden, response.ErrCodeClientForbidden, "权限不足")
-					c.Abort()
-					return
-				}
-			}
-		}
-
-		response.RespondError(c, http.StatusNotFound, response.ErrCodeClientResourceNotFound, "服务未找到 (Perm)")
-		c.Abort()
+		c.Next()
 	}
 }
@@ -0,0 +1,31 @@
+package middleware
+
+import (
+	"github.com/Xushengqwer/gateway/internal/apikey"
+	"github.com/Xushengqwer/gateway/internal/auth"
+	"github.com/Xushengqwer/gateway/internal/config"
+	"github.com/Xushengqwer/gateway/internal/core"
+)
+
+// NewAuthChain 按网关配置创建全部可用的认证方式，路由通过 auth 列表选择其中的若干种
+// - 未配置的认证方式不会加入认证链（配置校验已保证路由不会引用它们）
+// - apiKeys 为 nil 时忽略 keys
+func NewAuthChain(cfg *config.GatewayConfig, jwtUtil core.JWTUtilityInterface, keys *apikey.Store) *auth.Chain {
+	authenticators := []auth.Authenticator{
+		NewJWTAuthenticator(jwtUtil),
+		auth.NewAnonymous(),
+	}
+	if cfg.ClientCert != nil {
+		authenticators = append(authenticators, NewClientCertAuthenticator(cfg.ClientCert))
+	}
+	if cfg.APIKeys != nil && keys != nil {
+		authenticators = append(authenticators, NewAPIKeyAuthenticator(cfg.APIKeys, keys))
+	}
+	if cfg.HMACAuth != nil {
+		authenticators = append(authenticators, NewHMACAuthenticator(cfg.HMACAuth, cfg.Limits.MaxBodyBytes))
+	}
+	if cfg.BasicAuth != nil {
+		authenticators = append(authenticators, NewBasicAuthenticator(cfg.BasicAuth))
+	}
+	return auth.NewChain(authenticators...)
+}
@@ -0,0 +1,183 @@
+package openapi
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/Xushengqwer/gateway/internal/config"
+	"github.com/Xushengqwer/gateway/internal/policy"
+	"github.com/Xushengqwer/go-common/models/enums"
+)
+
+// 漂移类型
+const (
+	DriftMissing  = "missing"  // 文档中的操作在配置中没有对应规则，经网关访问返回 404
+	DriftMismatch = "mismatch" // 配置的访问要求与文档声明的不一致
+	DriftDenied   = "denied"   // 文档中的操作被拒绝规则拦截（仅提示，可能是有意为之）
+	DriftStale    = "stale"    // 配置中的 publicPaths / routes 未被文档中的任何操作命中（不匹配，或被优先的规则覆盖）
+	DriftUnmapped = "unmapped" // 文档中的操作不在服务的上游基础路径下，无法映射到网关路径
+)
+
+// Drift 是文档与服务配置之间的一处差异
+type Drift struct {
+	Kind    string
+	Method  string // 大写的 HTTP 方法，stale 为空
+	Path    string // 网关路径（stale 为配置中的路径模式）
+	Message string
+}
+
+// Counts 判断该差异是否计入漂移：denied 只作提示
+func (d Drift) Counts() bool {
+	return d.Kind != DriftDenied
+}
+
+// Diff 对比文档与服务当前配置：按网关运行时的匹配逻辑判断文档中每个操作的访问策略，再与文档声明的要求比较
+// - basePath 覆盖文档自身的 basePath / servers 路径
+// - 文档未声明访问要求的操作只检查是否有对应规则
+func Diff(doc *Document, svc *config.ServiceConfig, roles *config.RoleHierarchy, basePath string) []Drift {
+	engine := policy.New(svc, roles)
+	base := basePath
+	if base == "" {
+		base = doc.BasePath()
+	}
+	base = strings.TrimSuffix(base, "/")
+
+	var drifts []Drift
+	usedRoutes := make(map[*config.RouteConfig]bool)
+	usedRules := make(map[string]bool)
+	for _, op := range doc.Operations() {
+		method := strings.ToUpper(op.Method)
+		gatewayPath, ok := GatewayPath(svc, base+op.Path)
+		if !ok {
+			drifts = append(drifts, Drift{Kind: DriftUnmapped, Method: method, Path: base + op.Path,
+				Message: fmt.Sprintf("不在上游基础路径 %q 下", svc.UpstreamBasePath())})
+			continue
+		}
+		res := engine.ResolveTemplate(policy.RelativePath(svc.Prefix, gatewayPath), method)
+		if res.Route != nil {
+			usedRoutes[res.Route] = true
+		}
+		usedRules[res.Rule] = true
+
+		drift := Drift{Method: method, Path: gatewayPath}
+		switch res.Access {
+		case policy.AccessNotFound:
+			drift.Kind, drift.Message = DriftMissing, "配置中没有对应规则（"+res.Rule+"，返回 404）"
+			drifts = append(drifts, drift)
+			continue
+		case policy.AccessDenied:
+			drift.Kind, drift.Message = DriftDenied, "被 "+res.Rule+" 拒绝"
+			drifts = append(drifts, drift)
+			continue
+		}
+
+		req, _ := doc.Requirement(op)
+		if !req.Declared {
+			continue
+		}
+		drift.Kind = DriftMismatch
+		for _, msg := range compare(req, engine, res, roles) {
+			drift.Message = msg + "（" + res.Rule + "）"
+			drifts = append(drifts, drift)
+		}
+	}
+
+	for i := range svc.PublicPaths {
+		if !usedRules["public "+svc.PublicPaths[i]] {
+			drifts = append(drifts, Drift{Kind: DriftStale, Path: svc.PublicPaths[i], Message: "publicPaths 未被文档中的任何操作命中（不匹配，或被 deny 规则覆盖）"})
+		}
+	}
+	for i := range svc.Routes {
+		if route := &svc.Routes[i]; !usedRoutes[route] {
+			drifts = append(drifts, Drift{Kind: DriftStale, Path: route.Path, Message: policy.RouteRule(route) + " 未被文档中的任何操作命中（不匹配，或被 deny 规则、publicPaths、更具体的路由覆盖）"})
+		}
+	}
+	return drifts
+}
+
+// compare 返回配置与文档声明不一致之处
+func compare(req Requirement, engine *policy.Engine, res policy.Resolution, roles *config.RoleHierarchy) []string {
+	public := publicAccess(engine, res, roles)
+	if req.Public != public {
+		if req.Public {
+			return []string{"文档声明为公开，配置要求认证"}
+		}
+		return []string{"文档要求认证，配置为公开"}
+	}
+	if req.Public {
+		return nil
+	}
+
+	var diffs []string
+	if len(req.Roles) > 0 {
+		var actual []string
+		if res.Route != nil {
+			actual = RoleNames(res.Route, roles)
+		}
+		if !sameSet(req.Roles, actual) {
+			diffs = append(diffs, fmt.Sprintf("文档要求角色 %s，配置为 %s", listString(req.Roles), listString(actual)))
+		}
+	}
+	if !req.Scopes.Empty() {
+		var actual *config.ScopeRequirement
+		if res.Route != nil {
+			actual = res.Route.Scopes
+		}
+		if !sameScopes(req.Scopes, actual) {
+			diffs = append(diffs, fmt.Sprintf("文档要求授权范围 %s，配置为 %s", scopeString(req.Scopes), scopeString(actual)))
+		}
+	}
+	if req.Auth != nil {
+		if actual := engine.AuthSchemes(res); !sameSet(req.Auth, actual) {
+			diffs = append(diffs, fmt.Sprintf("文档接受的认证方式为 %s，配置为 %s", listString(req.Auth), listString(actual)))
+		}
+	}
+	return diffs
+}
+
+// publicAccess 判断请求是否无需凭证即可访问：不经过认证，或路由接受 none 且允许访客
+func publicAccess(engine *policy.Engine, res policy.Resolution, roles *config.RoleHierarchy) bool {
+	if !res.NeedsAuth() {
+		return true
+	}
+	if res.Route == nil || !res.Route.Permits(roles, enums.RoleGuest, nil) {
+		return false
+	}
+	for _, scheme := range engine.AuthSchemes(res) {
+		if scheme == config.AuthSchemeNone {
+			return true
+		}
+	}
+	return false
+}
+
+func sameScopes(a, b *config.ScopeRequirement) bool {
+	if a.Empty() || b.Empty() {
+		return a.Empty() == b.Empty()
+	}
+	return sameSet(a.AllOf, b.AllOf) && sameSet(a.AnyOf, b.AnyOf) && a.RequiresRole() == b.RequiresRole()
+}
+
+func scopeString(s *config.ScopeRequirement) string {
+	if s.Empty() {
+		return "[]"
+	}
+	var parts []string
+	if len(s.AllOf) > 0 {
+		parts = append(parts, "allOf "+listString(s.AllOf))
+	}
+	if len(s.AnyOf) > 0 {
+		parts = append(parts, "anyOf "+listString(s.AnyOf))
+	}
+	if s.RequiresRole() {
+		parts = append(parts, "mode all")
+	}
+	return strings.Join(parts, " ")
+}
+
+func listString(list []string) string {
+	sorted := append([]string(nil), list...)
+	sort.Strings(sorted)
+	return "[" + strings.Join(sorted, ", ") + "]"
+}
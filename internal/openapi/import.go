@@ -0,0 +1,341 @@
+package openapi
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/Xushengqwer/gateway/internal/config"
+	"github.com/Xushengqwer/gateway/internal/pathpattern"
+	"github.com/Xushengqwer/gateway/internal/policy"
+	"github.com/Xushengqwer/go-common/models/enums"
+)
+
+// Requirement 是文档为单个操作声明的访问要求
+// - 扩展字段优先：x-gateway-public / x-gateway-roles / x-gateway-scopes / x-gateway-auth
+// - 其次是 security（操作级覆盖顶层）：security: [] 表示公开，各安全方案映射为网关的认证方式，oauth2 scope 映射为 scopes
+type Requirement struct {
+	Declared bool     // 文档是否声明了任何访问要求，未声明时导入按默认最低角色处理，对比时不检查
+	Public   bool     // 无需认证
+	Roles    []string // x-gateway-roles 列出的角色名
+	Scopes   *config.ScopeRequirement
+	Auth     []string // 接受的认证方式，nil 表示文档未声明
+}
+
+// Requirement 解析操作的访问要求，warnings 为无法精确映射的部分
+func (d *Document) Requirement(op Operation) (Requirement, []string) {
+	var (
+		req      Requirement
+		warnings []string
+	)
+	if public, ok := op.Raw["x-gateway-public"].(bool); ok {
+		req.Declared, req.Public = true, public
+	}
+	if roles := stringList(op.Raw["x-gateway-roles"]); len(roles) > 0 {
+		req.Declared, req.Public, req.Roles = true, false, roles
+	}
+	if scopes, ok := op.Raw["x-gateway-scopes"].(map[string]any); ok {
+		req.Scopes = &config.ScopeRequirement{AllOf: stringList(scopes["allOf"]), AnyOf: stringList(scopes["anyOf"])}
+		req.Scopes.Mode, _ = scopes["mode"].(string)
+		req.Declared, req.Public = true, false
+	}
+	if auth := stringList(op.Raw["x-gateway-auth"]); len(auth) > 0 {
+		req.Declared, req.Auth = true, auth
+	}
+	if req.Public {
+		return req, warnings
+	}
+
+	security, ok := op.Raw["security"].([]any)
+	if !ok {
+		security, ok = d.Raw["security"].([]any)
+	}
+	if !ok {
+		return req, warnings
+	}
+	if len(security) == 0 {
+		if !req.Declared {
+			req.Declared, req.Public = true, true
+		}
+		return req, warnings
+	}
+
+	var (
+		auth   []string
+		scoped [][]string
+	)
+	for _, item := range security {
+		requirement, _ := item.(map[string]any)
+		if len(requirement) == 0 {
+			auth = appendUnique(auth, config.AuthSchemeNone)
+			continue
+		}
+		if len(requirement) > 1 {
+			warnings = append(warnings, fmt.Sprintf("%s %s 要求同时满足多个安全方案，网关按其中任一方案认证", strings.ToUpper(op.Method), op.Path))
+		}
+		names := make([]string, 0, len(requirement))
+		for name := range requirement {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			scheme, ok := d.authScheme(name)
+			if !ok {
+				warnings = append(warnings, fmt.Sprintf("%s %s 引用了未定义或无法映射的安全方案 %q", strings.ToUpper(op.Method), op.Path, name))
+				continue
+			}
+			auth = appendUnique(auth, scheme)
+			if scopes := stringList(requirement[name]); len(scopes) > 0 {
+				scoped = append(scoped, scopes)
+			}
+		}
+	}
+	req.Declared, req.Public = true, false
+	if req.Auth == nil {
+		req.Auth = auth
+	}
+	if req.Scopes == nil && len(scoped) > 0 {
+		req.Scopes = securityScopes(scoped)
+	}
+	return req, warnings
+}
+
+// authScheme 把文档中的安全方案映射为网关的认证方式
+// - http bearer、oauth2、openIdConnect 与名为 Authorization 的 apiKey 视为 jwt（与聚合文档的写法一致）
+func (d *Document) authScheme(name string) (string, bool) {
+	var definitions map[string]any
+	if d.IsSwagger2() {
+		definitions, _ = d.Raw["securityDefinitions"].(map[string]any)
+	} else if components, ok := d.Raw["components"].(map[string]any); ok {
+		definitions, _ = components["securitySchemes"].(map[string]any)
+	}
+	definition, ok := definitions[name].(map[string]any)
+	if !ok {
+		return "", false
+	}
+	typ, _ := definition["type"].(string)
+	switch strings.ToLower(typ) {
+	case "basic":
+		return config.AuthSchemeBasic, true
+	case "http":
+		scheme, _ := definition["scheme"].(string)
+		switch strings.ToLower(scheme) {
+		case "basic":
+			return config.AuthSchemeBasic, true
+		case "bearer":
+			return config.AuthSchemeJWT, true
+		}
+		return "", false
+	case "apikey":
+		if header, _ := definition["name"].(string); strings.EqualFold(header, "Authorization") {
+			if description, _ := definition["description"].(string); strings.Contains(description, "HMAC") {
+				return config.AuthSchemeHMAC, true
+			}
+			return config.AuthSchemeJWT, true
+		}
+		return config.AuthSchemeAPIKey, true
+	case "oauth2", "openidconnect":
+		return config.AuthSchemeJWT, true
+	case "mutualtls":
+		return config.AuthSchemeMTLS, true
+	}
+	return "", false
+}
+
+// securityScopes 把各安全要求中的 scope 转换为 scopes：只有一组时全部必需（allOf），多组且每组一个时满足其一（anyOf）
+func securityScopes(scoped [][]string) *config.ScopeRequirement {
+	if len(scoped) == 1 {
+		return &config.ScopeRequirement{AllOf: scoped[0]}
+	}
+	var anyOf []string
+	for _, scopes := range scoped {
+		if len(scopes) != 1 {
+			return &config.ScopeRequirement{AllOf: scoped[0]}
+		}
+		anyOf = appendUnique(anyOf, scopes[0])
+	}
+	return &config.ScopeRequirement{AnyOf: anyOf}
+}
+
+// ImportOptions 是从文档生成服务配置的设置
+type ImportOptions struct {
+	BasePath string // 覆盖文档自身的 basePath / servers 路径
+	MinRole  string // 需要认证但文档未声明角色与 scope 的操作使用的最低角色
+}
+
+// Import 按文档中的操作生成服务的 routes 与 publicPaths，svc 的其余字段（名称、前缀、上游）由调用方设置
+//   - svc.Prefix 为空时使用文档的基础路径；前缀与基础路径不同时设置 upstreamPrefix（基础路径为空时 stripPrefix），
+//     已设置 upstreamPrefix / stripPrefix 的服务保持不变
+//   - 同一路径的所有操作都公开时写入 publicPaths，否则公开的操作生成 auth: [none] 且 allowedRoles 为访客的路由
+//   - operationId 用作路由名称，summary 用作描述，tags 原样保留
+//
+// 返回无法映射的操作等警告
+func Import(doc *Document, svc *config.ServiceConfig, opts ImportOptions) []string {
+	var warnings []string
+	base := opts.BasePath
+	if base == "" {
+		base = doc.BasePath()
+	}
+	base = strings.TrimSuffix(base, "/")
+	if svc.Prefix == "" {
+		svc.Prefix = base
+	}
+	svc.Prefix = config.NormalizePrefix(svc.Prefix)
+	if svc.UpstreamPrefix == "" && !svc.StripPrefix && base != svc.Prefix {
+		if base == "" {
+			svc.StripPrefix = true
+		} else {
+			svc.UpstreamPrefix = base
+		}
+	}
+	svc.Routes, svc.PublicPaths = nil, nil
+
+	type entry struct {
+		op  Operation
+		req Requirement
+	}
+	var (
+		order  []string
+		byPath = make(map[string][]entry)
+		names  = make(map[string]bool)
+	)
+	for _, op := range doc.Operations() {
+		gatewayPath, ok := GatewayPath(svc, base+op.Path)
+		if !ok {
+			warnings = append(warnings, fmt.Sprintf("%s %s 不在上游基础路径 %q 下，已跳过", strings.ToUpper(op.Method), base+op.Path, svc.UpstreamBasePath()))
+			continue
+		}
+		path := ConfigPath(policy.RelativePath(svc.Prefix, gatewayPath))
+		if _, err := pathpattern.Parse(path); err != nil {
+			warnings = append(warnings, fmt.Sprintf("%s %s 无法转换为路径模式: %v", strings.ToUpper(op.Method), op.Path, err))
+			continue
+		}
+		req, w := doc.Requirement(op)
+		warnings = append(warnings, w...)
+		if _, ok := byPath[path]; !ok {
+			order = append(order, path)
+		}
+		byPath[path] = append(byPath[path], entry{op: op, req: req})
+	}
+
+	for _, path := range order {
+		entries := byPath[path]
+		allPublic := true
+		for _, e := range entries {
+			allPublic = allPublic && e.req.Public
+		}
+		if allPublic {
+			svc.PublicPaths = append(svc.PublicPaths, path)
+			continue
+		}
+		for _, e := range entries {
+			route := config.RouteConfig{Path: path, Methods: []string{strings.ToUpper(e.op.Method)}}
+			if id, _ := e.op.Raw["operationId"].(string); routeName.MatchString(id) && !names[id] {
+				names[id] = true
+				route.Name = id
+			}
+			route.Description, _ = e.op.Raw["summary"].(string)
+			for _, tag := range stringList(e.op.Raw["tags"]) {
+				if tag != "" && !strings.ContainsAny(tag, ", \t") {
+					route.Tags = append(route.Tags, tag)
+				}
+			}
+			switch {
+			case e.req.Public:
+				route.AllowedRoles = []enums.UserRole{enums.RoleGuest}
+				route.Auth = []string{config.AuthSchemeNone}
+			default:
+				route.Roles = e.req.Roles
+				route.Scopes = e.req.Scopes
+				if route.Scopes.RequiresRole() && len(route.Roles) == 0 {
+					route.MinRole = opts.MinRole
+				}
+				if len(route.Roles) == 0 && route.Scopes.Empty() {
+					route.MinRole = opts.MinRole
+				}
+				if !sameSet(e.req.Auth, config.DefaultAuthSchemes) {
+					route.Auth = e.req.Auth
+				}
+			}
+			svc.Routes = append(svc.Routes, route)
+		}
+	}
+	return warnings
+}
+
+// ConfigPath 把文档中的路径模板转换为配置中的路径模式："{post-id}" 转为 ":post_id"
+func ConfigPath(path string) string {
+	segs := strings.Split(path, "/")
+	for i, seg := range segs {
+		if len(seg) > 2 && strings.HasPrefix(seg, "{") && strings.HasSuffix(seg, "}") {
+			segs[i] = ":" + paramName(seg[1:len(seg)-1])
+		}
+	}
+	return strings.Join(segs, "/")
+}
+
+// routeName 与 config.Validate 对路由名称的限制一致
+var routeName = regexp.MustCompile(`^[A-Za-z0-9_.:-]+$`)
+
+// paramName 把参数名中路径模式不接受的字符替换为下划线
+func paramName(name string) string {
+	var b strings.Builder
+	for i, r := range name {
+		switch {
+		case r == '_' || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z'):
+			b.WriteRune(r)
+		case r >= '0' && r <= '9':
+			if i == 0 {
+				b.WriteByte('_')
+			}
+			b.WriteRune(r)
+		default:
+			b.WriteByte('_')
+		}
+	}
+	return b.String()
+}
+
+func stringList(v any) []string {
+	switch t := v.(type) {
+	case string:
+		return []string{t}
+	case []string:
+		return t
+	case []any:
+		list := make([]string, 0, len(t))
+		for _, item := range t {
+			if s, ok := item.(string); ok {
+				list = append(list, s)
+			}
+		}
+		return list
+	}
+	return nil
+}
+
+func appendUnique(list []string, s string) []string {
+	for _, item := range list {
+		if item == s {
+			return list
+		}
+	}
+	return append(list, s)
+}
+
+// sameSet 判断两个列表包含的元素是否相同（忽略顺序与重复）
+func sameSet(a, b []string) bool {
+	set := make(map[string]bool, len(a))
+	for _, s := range a {
+		set[s] = true
+	}
+	other := make(map[string]bool, len(b))
+	for _, s := range b {
+		if !set[s] {
+			return false
+		}
+		other[s] = true
+	}
+	return len(set) == len(other)
+}
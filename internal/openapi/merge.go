@@ -0,0 +1,514 @@
+package openapi
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/Xushengqwer/gateway/internal/config"
+	"github.com/Xushengqwer/gateway/internal/policy"
+)
+
+// 合并文档中由网关定义的安全方案名称
+const (
+	SchemeJWT    = "gatewayJWT"
+	SchemeBasic  = "gatewayBasic"
+	SchemeAPIKey = "gatewayAPIKey"
+	SchemeHMAC   = "gatewayHMAC"
+)
+
+// Source 是参与合并的一个服务文档
+type Source struct {
+	Service  *config.ServiceConfig
+	Engine   *policy.Engine // 服务的策略引擎，用于标注认证要求并排除网关不会放行的操作
+	Location string         // 文档来源（文件路径或地址），写入 x-gateway-sources
+	BasePath string         // 覆盖文档自身的 basePath / servers 路径，为空时使用文档中的值
+	Doc      *Document      // 加载失败时为 nil
+	Err      error          // 加载失败的原因
+}
+
+// MergeOptions 是合并文档的全局设置
+type MergeOptions struct {
+	Title        string
+	Version      string
+	Roles        *config.RoleHierarchy // 用于把 allowedRoles 的数值转换为角色名
+	APIKeyHeader string                // apikey 认证方式读取 Key 的请求头
+}
+
+// SourceStatus 是单个文档的合并结果，写入合并文档的 x-gateway-sources
+type SourceStatus struct {
+	Service    string   `json:"service"`
+	Location   string   `json:"location"`
+	Operations int      `json:"operations"`         // 合并进文档的操作数
+	Skipped    int      `json:"skipped,omitempty"`  // 无法映射到网关路径、被策略拒绝或与其他服务冲突的操作数
+	Warnings   []string `json:"warnings,omitempty"` // 被跳过操作的说明
+	Error      string   `json:"error,omitempty"`    // 文档加载或合并失败的原因
+}
+
+// Merge 把各服务的文档合并为一份经网关访问的文档
+//   - 文档路径按 basePath 还原为上游路径，再按服务的 upstreamPrefix / stripPrefix 映射回网关公开路径；
+//     路由 rewrite 与服务级 rewrites 不参与映射，无法映射的操作被跳过
+//   - 每个操作按服务策略标注 security 与 x-gateway-* 扩展；命中拒绝规则或默认策略为 deny 时不出现在文档中
+//   - 各服务的 definitions / components 以 "<服务名>." 为前缀避免重名，服务自身的安全方案被网关的认证方式取代
+//   - 合并文档的版本（Swagger 2.0 / OpenAPI 3）跟随第一个加载成功的文档，版本不同的文档被跳过
+func Merge(sources []Source, opts MergeOptions) (map[string]any, []SourceStatus) {
+	m := &merger{
+		opts:       opts,
+		paths:      make(map[string]map[string]any),
+		components: make(map[string]map[string]any),
+		schemes:    make(map[string]bool),
+		tagIndex:   make(map[string]bool),
+		operations: make(map[string]bool),
+	}
+	statuses := make([]SourceStatus, 0, len(sources))
+	for i := range sources {
+		src := &sources[i]
+		status := SourceStatus{Service: src.Service.Name, Location: src.Location}
+		switch {
+		case src.Err != nil:
+			status.Error = src.Err.Error()
+		case m.version != "" && src.Doc.IsSwagger2() != m.swagger2:
+			status.Error = fmt.Sprintf("文档版本 %s 与合并文档版本 %s 不一致，已跳过", src.Doc.Version(), m.version)
+		default:
+			if m.version == "" {
+				m.version, m.swagger2 = src.Doc.Version(), src.Doc.IsSwagger2()
+			}
+			m.add(src, &status)
+		}
+		statuses = append(statuses, status)
+	}
+	return m.output(statuses), statuses
+}
+
+type merger struct {
+	opts     MergeOptions
+	version  string
+	swagger2 bool
+
+	paths      map[string]map[string]any // 网关路径 -> Path Item
+	components map[string]map[string]any // 2.0 为 definitions / parameters / responses，3.x 为 components 下的各类
+	schemes    map[string]bool           // 用到的网关安全方案
+	tags       []any
+	tagIndex   map[string]bool
+	operations map[string]bool // 已使用的 operationId
+}
+
+// add 合并单个服务的文档
+func (m *merger) add(src *Source, status *SourceStatus) {
+	svc := src.Service
+	raw := copyValue(src.Doc.Raw).(map[string]any)
+	namespace := svc.Name + "."
+	rewriteRefs(raw, namespace, m.swagger2)
+	m.addComponents(raw, namespace)
+	m.addTags(raw)
+
+	base := src.BasePath
+	if base == "" {
+		base = src.Doc.BasePath()
+	}
+	base = strings.TrimSuffix(base, "/")
+	consumes, produces := raw["consumes"], raw["produces"]
+
+	paths, _ := raw["paths"].(map[string]any)
+	keys := make([]string, 0, len(paths))
+	for p := range paths {
+		keys = append(keys, p)
+	}
+	sort.Strings(keys)
+
+	usedServiceTag := false
+	for _, specPath := range keys {
+		item, ok := paths[specPath].(map[string]any)
+		if !ok {
+			continue
+		}
+		gatewayPath, ok := GatewayPath(svc, base+specPath)
+		if !ok {
+			for _, method := range Methods {
+				if _, ok := item[method]; ok {
+					status.Skipped++
+				}
+			}
+			status.Warnings = append(status.Warnings, fmt.Sprintf("%s 不在上游基础路径 %q 下，无法映射到网关路径", base+specPath, svc.UpstreamBasePath()))
+			continue
+		}
+		subPath := policy.RelativePath(svc.Prefix, gatewayPath)
+
+		out := make(map[string]any)
+		for _, method := range Methods {
+			op, ok := item[method].(map[string]any)
+			if !ok {
+				continue
+			}
+			upper := strings.ToUpper(method)
+			if existing, ok := m.paths[gatewayPath]; ok && existing[method] != nil {
+				status.Skipped++
+				status.Warnings = append(status.Warnings, fmt.Sprintf("%s %s 已由其他服务定义", upper, gatewayPath))
+				continue
+			}
+			res := src.Engine.ResolveTemplate(subPath, upper)
+			if res.Access == policy.AccessDenied || res.Access == policy.AccessNotFound {
+				status.Skipped++
+				continue
+			}
+
+			if m.swagger2 {
+				if _, ok := op["consumes"]; !ok && consumes != nil {
+					op["consumes"] = consumes
+				}
+				if _, ok := op["produces"]; !ok && produces != nil {
+					op["produces"] = produces
+				}
+			} else {
+				delete(op, "servers")
+			}
+			if tags, _ := op["tags"].([]any); len(tags) == 0 {
+				op["tags"] = []any{svc.Name}
+				usedServiceTag = true
+			}
+			if id, ok := op["operationId"].(string); ok && id != "" {
+				if m.operations[id] {
+					id = namespace + id
+					op["operationId"] = id
+				}
+				m.operations[id] = true
+			}
+			m.annotate(op, src.Engine, res)
+			out[method] = op
+			status.Operations++
+		}
+		if len(out) == 0 {
+			continue
+		}
+
+		target, ok := m.paths[gatewayPath]
+		if !ok {
+			target = make(map[string]any)
+			m.paths[gatewayPath] = target
+		}
+		for key, value := range item {
+			if isMethod(key) || (!m.swagger2 && key == "servers") {
+				continue
+			}
+			if _, ok := target[key]; !ok {
+				target[key] = value
+			}
+		}
+		for method, op := range out {
+			target[method] = op
+		}
+	}
+	if usedServiceTag && !m.tagIndex[svc.Name] {
+		m.tagIndex[svc.Name] = true
+		m.tags = append(m.tags, map[string]any{"name": svc.Name})
+	}
+}
+
+// addComponents 以 namespace 为前缀登记文档中的可复用定义，丢弃服务自身的安全方案
+func (m *merger) addComponents(raw map[string]any, namespace string) {
+	add := func(kind string, items map[string]any) {
+		if m.components[kind] == nil {
+			m.components[kind] = make(map[string]any)
+		}
+		for name, item := range items {
+			m.components[kind][namespace+name] = item
+		}
+	}
+	if m.swagger2 {
+		for _, kind := range []string{"definitions", "parameters", "responses"} {
+			if items, ok := raw[kind].(map[string]any); ok {
+				add(kind, items)
+			}
+		}
+		return
+	}
+	components, _ := raw["components"].(map[string]any)
+	for kind, value := range components {
+		if items, ok := value.(map[string]any); ok && kind != "securitySchemes" {
+			add(kind, items)
+		}
+	}
+}
+
+// addTags 登记文档顶层的标签定义，同名标签保留第一个
+func (m *merger) addTags(raw map[string]any) {
+	tags, _ := raw["tags"].([]any)
+	for _, t := range tags {
+		tag, ok := t.(map[string]any)
+		if !ok {
+			continue
+		}
+		name, _ := tag["name"].(string)
+		if name == "" || m.tagIndex[name] {
+			continue
+		}
+		m.tagIndex[name] = true
+		m.tags = append(m.tags, tag)
+	}
+}
+
+// annotate 按策略匹配结果写入操作的 security 与 x-gateway-* 扩展
+func (m *merger) annotate(op map[string]any, engine *policy.Engine, res policy.Resolution) {
+	op["x-gateway-access"] = AccessName(res.Access)
+	op["x-gateway-rule"] = res.Rule
+	if res.Route != nil {
+		if res.Route.Name != "" {
+			op["x-gateway-route"] = res.Route.Name
+		}
+		if roles := RoleNames(res.Route, m.opts.Roles); len(roles) > 0 {
+			op["x-gateway-roles"] = roles
+		}
+		if !res.Route.Scopes.Empty() {
+			scopes := make(map[string]any)
+			if len(res.Route.Scopes.AllOf) > 0 {
+				scopes["allOf"] = res.Route.Scopes.AllOf
+			}
+			if len(res.Route.Scopes.AnyOf) > 0 {
+				scopes["anyOf"] = res.Route.Scopes.AnyOf
+			}
+			if res.Route.Scopes.RequiresRole() {
+				scopes["mode"] = config.ScopeModeAll
+			}
+			op["x-gateway-scopes"] = scopes
+		}
+	}
+
+	if !res.NeedsAuth() {
+		op["x-gateway-public"] = true
+		op["security"] = []any{}
+		return
+	}
+	schemes := engine.AuthSchemes(res)
+	op["x-gateway-auth"] = schemes
+	security := make([]any, 0, len(schemes))
+	for _, scheme := range schemes {
+		switch scheme {
+		case config.AuthSchemeNone:
+			security = append(security, map[string]any{})
+		case config.AuthSchemeJWT, config.AuthSchemeBasic, config.AuthSchemeAPIKey, config.AuthSchemeHMAC:
+			name := securitySchemeName(scheme)
+			m.schemes[name] = true
+			security = append(security, map[string]any{name: []any{}})
+		}
+		// mtls 无法用 OpenAPI 安全方案表达，只体现在 x-gateway-auth 中
+	}
+	if len(security) > 0 {
+		op["security"] = security
+	} else {
+		delete(op, "security")
+	}
+}
+
+// output 组装合并后的文档
+func (m *merger) output(statuses []SourceStatus) map[string]any {
+	info := map[string]any{"title": m.opts.Title, "version": m.opts.Version}
+	paths := make(map[string]any, len(m.paths))
+	for p, item := range m.paths {
+		paths[p] = item
+	}
+	doc := map[string]any{"info": info, "paths": paths, "x-gateway-sources": statuses}
+	if len(m.tags) > 0 {
+		doc["tags"] = m.tags
+	}
+
+	schemes := make(map[string]any, len(m.schemes))
+	for name := range m.schemes {
+		schemes[name] = m.securityScheme(name)
+	}
+	if m.swagger2 {
+		doc["swagger"] = "2.0"
+		doc["basePath"] = "/"
+		for kind, items := range m.components {
+			if len(items) > 0 {
+				doc[kind] = items
+			}
+		}
+		if len(schemes) > 0 {
+			doc["securityDefinitions"] = schemes
+		}
+		return doc
+	}
+
+	version := m.version
+	if version == "" {
+		version = "3.0.3"
+	}
+	doc["openapi"] = version
+	doc["servers"] = []any{map[string]any{"url": "/"}}
+	components := make(map[string]any, len(m.components)+1)
+	for kind, items := range m.components {
+		if len(items) > 0 {
+			components[kind] = items
+		}
+	}
+	if len(schemes) > 0 {
+		components["securitySchemes"] = schemes
+	}
+	if len(components) > 0 {
+		doc["components"] = components
+	}
+	return doc
+}
+
+// securityScheme 返回网关安全方案的定义
+func (m *merger) securityScheme(name string) map[string]any {
+	header := m.opts.APIKeyHeader
+	if header == "" {
+		header = config.DefaultAPIKeyHeader
+	}
+	switch name {
+	case SchemeBasic:
+		if m.swagger2 {
+			return map[string]any{"type": "basic"}
+		}
+		return map[string]any{"type": "http", "scheme": "basic"}
+	case SchemeAPIKey:
+		return map[string]any{"type": "apiKey", "in": "header", "name": header}
+	case SchemeHMAC:
+		return map[string]any{"type": "apiKey", "in": "header", "name": "Authorization", "description": "GW-HMAC-SHA256 请求签名"}
+	default:
+		if m.swagger2 {
+			return map[string]any{"type": "apiKey", "in": "header", "name": "Authorization", "description": "Bearer <access token>"}
+		}
+		return map[string]any{"type": "http", "scheme": "bearer", "bearerFormat": "JWT"}
+	}
+}
+
+// GatewayPath 把上游路径映射为网关公开路径，即 UpstreamBasePath 的逆过程
+// - 上游路径不在服务的上游基础路径下（按整段比较）时返回 false
+func GatewayPath(svc *config.ServiceConfig, upstreamPath string) (string, bool) {
+	rest := upstreamPath
+	if base := svc.UpstreamBasePath(); base != "" {
+		switch {
+		case upstreamPath == base:
+			rest = ""
+		case strings.HasPrefix(upstreamPath, base+"/"):
+			rest = upstreamPath[len(base):]
+		default:
+			return "", false
+		}
+	}
+	gatewayPath := strings.TrimSuffix(svc.Prefix, "/") + rest
+	if gatewayPath == "" {
+		gatewayPath = "/"
+	}
+	return gatewayPath, true
+}
+
+// AccessName 返回访问类型在文档中的名称
+func AccessName(access policy.Access) string {
+	switch access {
+	case policy.AccessPublic:
+		return "public"
+	case policy.AccessRoute:
+		return "route"
+	case policy.AccessAuthenticated:
+		return "authenticated"
+	case policy.AccessDenied:
+		return "denied"
+	default:
+		return "notFound"
+	}
+}
+
+// RoleNames 返回路由允许的角色名：roles / minRole 原样列出，allowedRoles 的数值按角色表转换为名称
+func RoleNames(route *config.RouteConfig, roles *config.RoleHierarchy) []string {
+	var names []string
+	seen := make(map[string]bool)
+	add := func(name string) {
+		if !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+	for _, role := range route.AllowedRoles {
+		if roles != nil {
+			add(roles.Name(role))
+		} else {
+			add(role.String())
+		}
+	}
+	for _, name := range route.RequiredRoleNames() {
+		add(name)
+	}
+	return names
+}
+
+func securitySchemeName(scheme string) string {
+	switch scheme {
+	case config.AuthSchemeBasic:
+		return SchemeBasic
+	case config.AuthSchemeAPIKey:
+		return SchemeAPIKey
+	case config.AuthSchemeHMAC:
+		return SchemeHMAC
+	default:
+		return SchemeJWT
+	}
+}
+
+func isMethod(key string) bool {
+	for _, m := range Methods {
+		if key == m {
+			return true
+		}
+	}
+	return false
+}
+
+// rewriteRefs 为文档内引用可复用定义的 $ref 加上 namespace 前缀
+func rewriteRefs(v any, namespace string, swagger2 bool) {
+	switch t := v.(type) {
+	case map[string]any:
+		for k, val := range t {
+			if ref, ok := val.(string); ok && k == "$ref" {
+				t[k] = namespaceRef(ref, namespace, swagger2)
+				continue
+			}
+			rewriteRefs(val, namespace, swagger2)
+		}
+	case []any:
+		for _, val := range t {
+			rewriteRefs(val, namespace, swagger2)
+		}
+	}
+}
+
+func namespaceRef(ref, namespace string, swagger2 bool) string {
+	if swagger2 {
+		for _, kind := range []string{"definitions", "parameters", "responses"} {
+			if name, ok := strings.CutPrefix(ref, "#/"+kind+"/"); ok {
+				return "#/" + kind + "/" + namespace + name
+			}
+		}
+		return ref
+	}
+	rest, ok := strings.CutPrefix(ref, "#/components/")
+	if !ok {
+		return ref
+	}
+	kind, name, ok := strings.Cut(rest, "/")
+	if !ok || kind == "securitySchemes" {
+		return ref
+	}
+	return "#/components/" + kind + "/" + namespace + name
+}
+
+// copyValue 深拷贝文档，合并时的改写不影响缓存的原始文档
+func copyValue(v any) any {
+	switch t := v.(type) {
+	case map[string]any:
+		m := make(map[string]any, len(t))
+		for k, val := range t {
+			m[k] = copyValue(val)
+		}
+		return m
+	case []any:
+		s := make([]any, len(t))
+		for i, val := range t {
+			s[i] = copyValue(val)
+		}
+		return s
+	}
+	return v
+}
@@ -0,0 +1,164 @@
+// Package openapi 读取各服务的 OpenAPI 3 / Swagger 2.0 文档，供聚合文档与配置导入使用
+// - 文档保持为通用的 map 结构，只解释合并与导入用到的字段，其余内容原样保留
+package openapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// maxDocumentBytes 限制单个文档的大小，防止异常的上游响应占满内存
+const maxDocumentBytes = 32 << 20
+
+// Methods 是 Path Item 中表示操作的键，按输出顺序排列
+var Methods = []string{"get", "put", "post", "delete", "options", "head", "patch", "trace"}
+
+// Document 是解析后的文档
+type Document struct {
+	Raw map[string]any
+}
+
+// Operation 是文档中的一个操作（路径 + 方法）
+type Operation struct {
+	Path   string         // 文档中的路径模板，如 "/posts/{id}"
+	Method string         // 小写的 HTTP 方法
+	Raw    map[string]any // Operation Object
+}
+
+// Parse 解析 JSON 或 YAML 格式的文档
+func Parse(data []byte) (*Document, error) {
+	var raw map[string]any
+	if err := json.Unmarshal(data, &raw); err != nil {
+		if yerr := yaml.Unmarshal(data, &raw); yerr != nil {
+			return nil, fmt.Errorf("文档既不是有效的 JSON 也不是有效的 YAML: %w", yerr)
+		}
+		raw = normalizeYAML(raw).(map[string]any)
+	}
+	if raw == nil {
+		return nil, errors.New("文档为空")
+	}
+	doc := &Document{Raw: raw}
+	if !doc.IsSwagger2() && !strings.HasPrefix(doc.Version(), "3.") {
+		return nil, fmt.Errorf("不支持的文档版本 %q，仅支持 Swagger 2.0 与 OpenAPI 3.x", doc.Version())
+	}
+	return doc, nil
+}
+
+// Load 读取文档：location 为 http(s) 地址时通过 client 拉取，否则按文件路径读取
+func Load(ctx context.Context, client *http.Client, location string) (*Document, error) {
+	var data []byte
+	if u, err := url.Parse(location); err == nil && (u.Scheme == "http" || u.Scheme == "https") {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, location, nil)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Accept", "application/json, application/yaml;q=0.9, */*;q=0.8")
+		resp, err := client.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("拉取 %s 返回状态码 %d", location, resp.StatusCode)
+		}
+		data, err = io.ReadAll(io.LimitReader(resp.Body, maxDocumentBytes))
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		data, err = os.ReadFile(location)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return Parse(data)
+}
+
+// Version 返回文档声明的版本，如 "2.0"、"3.0.3"
+func (d *Document) Version() string {
+	if v, ok := d.Raw["swagger"].(string); ok {
+		return v
+	}
+	v, _ := d.Raw["openapi"].(string)
+	return v
+}
+
+// IsSwagger2 判断文档是否为 Swagger 2.0
+func (d *Document) IsSwagger2() bool {
+	return d.Version() == "2.0"
+}
+
+// BasePath 返回文档路径在上游的挂载点：Swagger 2.0 取 basePath，OpenAPI 3 取第一个 servers 的路径部分
+func (d *Document) BasePath() string {
+	var base string
+	if d.IsSwagger2() {
+		base, _ = d.Raw["basePath"].(string)
+	} else if servers, ok := d.Raw["servers"].([]any); ok && len(servers) > 0 {
+		if server, ok := servers[0].(map[string]any); ok {
+			if raw, ok := server["url"].(string); ok {
+				if u, err := url.Parse(raw); err == nil {
+					base = u.Path
+				}
+			}
+		}
+	}
+	return strings.TrimSuffix(base, "/")
+}
+
+// Operations 返回全部操作，按路径与方法排序
+func (d *Document) Operations() []Operation {
+	paths, _ := d.Raw["paths"].(map[string]any)
+	keys := make([]string, 0, len(paths))
+	for p := range paths {
+		keys = append(keys, p)
+	}
+	sort.Strings(keys)
+
+	var ops []Operation
+	for _, p := range keys {
+		item, ok := paths[p].(map[string]any)
+		if !ok {
+			continue
+		}
+		for _, m := range Methods {
+			if op, ok := item[m].(map[string]any); ok {
+				ops = append(ops, Operation{Path: p, Method: m, Raw: op})
+			}
+		}
+	}
+	return ops
+}
+
+// normalizeYAML 把 YAML 解码出的非字符串键 map 转换为 map[string]any，与 JSON 解码结果保持一致
+// - 例如响应码 200 在 YAML 中会被解码为整数键
+func normalizeYAML(v any) any {
+	switch t := v.(type) {
+	case map[string]any:
+		for k, val := range t {
+			t[k] = normalizeYAML(val)
+		}
+		return t
+	case map[any]any:
+		m := make(map[string]any, len(t))
+		for k, val := range t {
+			m[fmt.Sprint(k)] = normalizeYAML(val)
+		}
+		return m
+	case []any:
+		for i, val := range t {
+			t[i] = normalizeYAML(val)
+		}
+		return t
+	}
+	return v
+}
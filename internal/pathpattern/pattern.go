@@ -0,0 +1,184 @@
+// Package pathpattern 解析 publicPaths、routes、deny 与 cache 规则共用的路径模式语法
+//
+// 模式按 "/" 分段，每段为以下之一：
+//
+//	posts          静态段，精确匹配
+//	:id / {id}     参数，匹配任意一个非空段
+//	:id<[0-9]+>    带约束的参数，段必须整体匹配正则；也可写作 {id:[0-9]+}
+//	:id<int>       类型约束，int / uuid / alpha / alnum 为内置正则的简写
+//	*              匿名参数，匹配任意一个非空段
+//	*path          具名通配，匹配剩余的一个或多个段（值为以 "/" 连接的剩余路径），只能位于末尾
+//	**             多段通配，匹配零个或多个段，可位于任意位置，每个模式至多一个
+//
+// 参数后加 "?" 表示可选（如 /posts/:id?），可选段只能出现在模式末尾。
+// 路径首尾的 "/" 不参与匹配：/posts、/posts/ 与模式 "/posts/" 相互等价。
+//
+// 多个模式同时匹配时，按请求路径从左到右逐段比较各模式匹配该段所用的段类型，先出现更具体者胜出：
+//
+//	静态段 > 带约束参数 > 参数 / "*" > 具名通配 "*path" > "**"
+//
+// 逐段相同时，显式列出请求方法的规则优先于方法通配（methods 为空或含 "*"），仍相同则取配置中靠前的规则。
+package pathpattern
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Kind 是模式段的类型，数值越大越具体
+type Kind uint8
+
+const (
+	Multi      Kind = iota + 1 // "**"
+	CatchAll                   // "*path"
+	Param                      // ":id" / "{id}" / "*"
+	Constraint                 // ":id<re>" / "{id:re}"
+	Static                     // 静态段
+)
+
+// Segment 是解析后的一个模式段
+type Segment struct {
+	Kind     Kind
+	Value    string         // 静态段的文本；约束参数为约束的原始写法（用于共享前缀树节点）
+	Name     string         // 参数名，匿名参数与 "**" 为空
+	Re       *regexp.Regexp // Constraint 段的正则（已整体锚定）
+	Optional bool
+}
+
+// 内置类型约束
+var typeConstraints = map[string]string{
+	"int":   `[0-9]+`,
+	"uuid":  `[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}`,
+	"alpha": `[A-Za-z]+`,
+	"alnum": `[A-Za-z0-9]+`,
+}
+
+var namePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// Parse 解析路径模式并检查语法
+func Parse(pattern string) ([]Segment, error) {
+	if !strings.HasPrefix(pattern, "/") {
+		return nil, fmt.Errorf("路径模式必须以 \"/\" 开头: %q", pattern)
+	}
+	trimmed := strings.Trim(pattern, "/")
+	if trimmed == "" {
+		return nil, nil
+	}
+
+	raw := strings.Split(trimmed, "/")
+	segs := make([]Segment, 0, len(raw))
+	names := make(map[string]bool)
+	multi := false
+	for i, s := range raw {
+		seg, err := parseSegment(s)
+		if err != nil {
+			return nil, fmt.Errorf("路径模式 %q: %w", pattern, err)
+		}
+		if seg.Kind == CatchAll && i != len(raw)-1 {
+			return nil, fmt.Errorf("路径模式 %q: 具名通配 %q 只能位于末尾", pattern, s)
+		}
+		if seg.Kind == Multi {
+			// 每多一个 "**"，前缀树匹配的回溯量就要再乘以请求路径的段数
+			if multi {
+				return nil, fmt.Errorf("路径模式 %q: 只能包含一个 \"**\"", pattern)
+			}
+			multi = true
+		}
+		if seg.Name != "" {
+			if names[seg.Name] {
+				return nil, fmt.Errorf("路径模式 %q: 参数名 %q 重复", pattern, seg.Name)
+			}
+			names[seg.Name] = true
+		}
+		if !seg.Optional && len(segs) > 0 && segs[len(segs)-1].Optional {
+			return nil, fmt.Errorf("路径模式 %q: 可选段只能出现在末尾", pattern)
+		}
+		segs = append(segs, seg)
+	}
+	return segs, nil
+}
+
+func parseSegment(s string) (Segment, error) {
+	optional := false
+	if strings.HasSuffix(s, "?") && (strings.HasPrefix(s, ":") || strings.HasPrefix(s, "{")) {
+		optional = true
+		s = s[:len(s)-1]
+	}
+
+	var seg Segment
+	switch {
+	case s == "**":
+		seg = Segment{Kind: Multi}
+	case s == "*":
+		seg = Segment{Kind: Param}
+	case strings.HasPrefix(s, "*"):
+		seg = Segment{Kind: CatchAll, Name: s[1:]}
+	case strings.HasPrefix(s, ":"):
+		name, constraint, ok := strings.Cut(s[1:], "<")
+		if ok {
+			if !strings.HasSuffix(constraint, ">") {
+				return Segment{}, fmt.Errorf("参数约束缺少 \">\": %q", s)
+			}
+			constraint = constraint[:len(constraint)-1]
+		}
+		seg = Segment{Kind: Param, Name: name, Value: constraint}
+	case strings.HasPrefix(s, "{"):
+		if !strings.HasSuffix(s, "}") {
+			return Segment{}, fmt.Errorf("参数缺少 \"}\": %q", s)
+		}
+		name, constraint, _ := strings.Cut(s[1:len(s)-1], ":")
+		seg = Segment{Kind: Param, Name: name, Value: constraint}
+	default:
+		if strings.Contains(s, "*") {
+			return Segment{}, fmt.Errorf("通配符必须独占一段: %q", s)
+		}
+		return Segment{Kind: Static, Value: s}, nil
+	}
+
+	if seg.Kind != Multi && !(seg.Kind == Param && s == "*") && !namePattern.MatchString(seg.Name) {
+		return Segment{}, fmt.Errorf("参数名无效: %q", s)
+	}
+	if seg.Kind == Param && seg.Value != "" {
+		expr := seg.Value
+		if builtin, ok := typeConstraints[expr]; ok {
+			expr = builtin
+		}
+		re, err := regexp.Compile("^(?:" + expr + ")$")
+		if err != nil {
+			return Segment{}, fmt.Errorf("参数 %q 的约束无效: %w", seg.Name, err)
+		}
+		seg.Kind, seg.Re = Constraint, re
+	}
+	if optional && seg.Kind != Param && seg.Kind != Constraint {
+		return Segment{}, fmt.Errorf("只有参数段可以是可选的: %q", s)
+	}
+	seg.Optional = optional
+	return seg, nil
+}
+
+// Expand 展开可选段，返回全部等价的必选模式（先短后长）
+// - 例如 /posts/:id? 展开为 /posts 与 /posts/:id
+func Expand(segs []Segment) [][]Segment {
+	first := len(segs)
+	for i, seg := range segs {
+		if seg.Optional {
+			first = i
+			break
+		}
+	}
+	out := make([][]Segment, 0, len(segs)-first+1)
+	for n := first; n <= len(segs); n++ {
+		out = append(out, segs[:n])
+	}
+	return out
+}
+
+// Split 把请求路径拆分为段，首尾的 "/" 不产生空段，根路径返回空切片
+func Split(path string) []string {
+	trimmed := strings.Trim(path, "/")
+	if trimmed == "" {
+		return nil
+	}
+	return strings.Split(trimmed, "/")
+}
@@ -0,0 +1,150 @@
+package pathpattern
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	cases := []struct {
+		pattern string
+		kinds   []Kind
+		names   []string
+		wantErr bool
+	}{
+		{pattern: "/", kinds: nil},
+		{pattern: "/posts/", kinds: []Kind{Static}, names: []string{""}},
+		{pattern: "/posts/:id", kinds: []Kind{Static, Param}, names: []string{"", "id"}},
+		{pattern: "/posts/{id}", kinds: []Kind{Static, Param}, names: []string{"", "id"}},
+		{pattern: "/posts/:id<int>", kinds: []Kind{Static, Constraint}, names: []string{"", "id"}},
+		{pattern: "/posts/:id<[0-9]+>", kinds: []Kind{Static, Constraint}, names: []string{"", "id"}},
+		{pattern: "/posts/{id:[0-9]+}", kinds: []Kind{Static, Constraint}, names: []string{"", "id"}},
+		{pattern: "/posts/*/comments", kinds: []Kind{Static, Param, Static}, names: []string{"", "", ""}},
+		{pattern: "/files/*path", kinds: []Kind{Static, CatchAll}, names: []string{"", "path"}},
+		{pattern: "/**/internal", kinds: []Kind{Multi, Static}, names: []string{"", ""}},
+		{pattern: "/posts/:id/:action?", kinds: []Kind{Static, Param, Param}, names: []string{"", "id", "action"}},
+
+		{pattern: "posts", wantErr: true},
+		{pattern: "/**/**", wantErr: true},
+		{pattern: "/**/internal/**", wantErr: true},
+		{pattern: "/files/*path/meta", wantErr: true},
+		{pattern: "/users/:id/posts/:id", wantErr: true},
+		{pattern: "/posts/:id?/comments", wantErr: true},
+		{pattern: "/posts/:id<[0-9+>", wantErr: true},
+		{pattern: "/posts/:id<int", wantErr: true},
+		{pattern: "/posts/{id", wantErr: true},
+		{pattern: "/posts/:1id", wantErr: true},
+		{pattern: "/posts/a*b", wantErr: true},
+		{pattern: "/files/*path?", wantErr: true},
+	}
+	for _, tc := range cases {
+		segs, err := Parse(tc.pattern)
+		if tc.wantErr {
+			if err == nil {
+				t.Errorf("Parse(%q) 期望返回错误", tc.pattern)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Parse(%q) 返回错误: %v", tc.pattern, err)
+			continue
+		}
+		var kinds []Kind
+		var names []string
+		for _, seg := range segs {
+			kinds = append(kinds, seg.Kind)
+			names = append(names, seg.Name)
+		}
+		if !reflect.DeepEqual(kinds, tc.kinds) || !reflect.DeepEqual(names, tc.names) {
+			t.Errorf("Parse(%q) 段类型 %v 参数名 %q，期望 %v %q", tc.pattern, kinds, names, tc.kinds, tc.names)
+		}
+	}
+}
+
+func TestParseConstraint(t *testing.T) {
+	cases := []struct {
+		pattern, seg string
+		want         bool
+	}{
+		{"/posts/:id<int>", "42", true},
+		{"/posts/:id<int>", "42a", false},
+		{"/posts/{id:[a-z]+}", "abc", true},
+		{"/posts/{id:[a-z]+}", "abc1", false},
+		{"/posts/:id<uuid>", "123e4567-e89b-12d3-a456-426614174000", true},
+		{"/posts/:id<alpha>", "abc1", false},
+		{"/posts/:id<alnum>", "abc1", true},
+	}
+	for _, tc := range cases {
+		segs, err := Parse(tc.pattern)
+		if err != nil {
+			t.Fatalf("Parse(%q) 返回错误: %v", tc.pattern, err)
+		}
+		if got := segs[1].Re.MatchString(tc.seg); got != tc.want {
+			t.Errorf("%q 约束匹配 %q = %v，期望 %v", tc.pattern, tc.seg, got, tc.want)
+		}
+	}
+}
+
+func TestExpand(t *testing.T) {
+	cases := []struct {
+		pattern string
+		want    []int // 每个展开结果的段数
+	}{
+		{"/", []int{0}},
+		{"/posts", []int{1}},
+		{"/posts/:id?", []int{1, 2}},
+		{"/posts/:id?/:action?", []int{1, 2, 3}},
+		{"/:lang?", []int{0, 1}},
+	}
+	for _, tc := range cases {
+		segs, err := Parse(tc.pattern)
+		if err != nil {
+			t.Fatalf("Parse(%q) 返回错误: %v", tc.pattern, err)
+		}
+		var got []int
+		for _, variant := range Expand(segs) {
+			if !reflect.DeepEqual(variant, segs[:len(variant)]) {
+				t.Errorf("Expand(%q) 的展开结果 %v 不是原模式的前缀", tc.pattern, variant)
+			}
+			got = append(got, len(variant))
+		}
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("Expand(%q) 段数 %v，期望 %v", tc.pattern, got, tc.want)
+		}
+	}
+}
+
+func TestSplit(t *testing.T) {
+	cases := []struct {
+		path string
+		want []string
+	}{
+		{"/", nil},
+		{"", nil},
+		{"/posts", []string{"posts"}},
+		{"/posts/", []string{"posts"}},
+		{"/posts/42/comments", []string{"posts", "42", "comments"}},
+	}
+	for _, tc := range cases {
+		if got := Split(tc.path); !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("Split(%q) = %q，期望 %q", tc.path, got, tc.want)
+		}
+	}
+}
+
+// TestKindOrder 确认 Kind 的数值顺序与包文档中的优先级一致：静态段 > 带约束参数 > 参数 / "*" > 具名通配 > "**"
+func TestKindOrder(t *testing.T) {
+	order := []string{"/posts", "/:id<int>", "/:id", "/*", "/*path", "/**"}
+	var prev Kind
+	for i, pattern := range order {
+		segs, err := Parse(pattern)
+		if err != nil {
+			t.Fatalf("Parse(%q) 返回错误: %v", pattern, err)
+		}
+		kind := segs[0].Kind
+		if i > 0 && kind > prev {
+			t.Errorf("%q 的段类型 %d 比前一个模式 %q 更具体", pattern, kind, order[i-1])
+		}
+		prev = kind
+	}
+}
@@ -0,0 +1,279 @@
+package policy
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/Xushengqwer/gateway/internal/auth"
+	"github.com/Xushengqwer/gateway/internal/config"
+	"github.com/Xushengqwer/go-common/models/enums"
+	"github.com/Xushengqwer/go-common/response"
+)
+
+// Access 是不依赖身份的第一阶段匹配结果
+type Access int
+
+const (
+	AccessNotFound      Access = iota // 未匹配任何规则且默认策略为 deny：404
+	AccessDenied                      // 命中无豁免角色的拒绝规则：403
+	AccessPublic                      // 公开路径或默认策略 public：无需认证
+	AccessRoute                       // 私有路由：按 allowedRoles / roles / minRole / scopes 判断
+	AccessAuthenticated               // 默认策略 authenticated：任意已认证身份
+)
+
+// Resolution 是请求在服务内的匹配结果
+type Resolution struct {
+	Access Access
+	Route  *config.RouteConfig // Access 为 AccessRoute 时命中的路由
+	Params map[string]string   // 命中路由的路径参数，如 {"id": "42"}
+	Rule   string              // 命中规则的描述，如 "route GET /posts/:id"、"deny /admin/**"，用于日志
+	Reason string              // AccessDenied 时拒绝规则的原因
+
+	// denies 是认证后由 Authorize 判断的拒绝规则：带 exceptRoles 的规则，以及试运行中的规则
+	denies []*config.DenyRule
+	// needsAuth 表示存在强制执行的带 exceptRoles 的拒绝规则，公开路径也必须先认证
+	needsAuth bool
+}
+
+// NeedsAuth 判断请求是否需要先经过认证链
+func (r Resolution) NeedsAuth() bool {
+	switch r.Access {
+	case AccessRoute, AccessAuthenticated:
+		return true
+	case AccessPublic:
+		return r.needsAuth
+	}
+	return false
+}
+
+// Decision 是授权判断结果，Allowed 为 false 时携带返回给客户端的状态码、业务码与消息
+// - Shadow 为 true 表示试运行规则本应拒绝该请求（Status / Code / Message 为本应返回的结果），但请求仍被放行
+type Decision struct {
+	Allowed bool
+	Shadow  bool
+	Status  int
+	Code    int
+	Message string
+	Rule    string // 作出该判断的规则描述
+	Reason  string // 拒绝规则配置的原因（可选）
+}
+
+// Engine 按统一的顺序判断服务内请求的访问策略：
+//  1. deny 规则（无 exceptRoles 的直接 403，有 exceptRoles 的认证后判断）
+//  2. publicPaths
+//  3. routes（取最佳匹配）
+//  4. defaultPolicy
+//
+// 各规则的 enforce: false（或服务级 enforce: false）表示试运行：规则照常判断，本应拒绝时返回 Shadow 结果并放行
+//
+// publicPaths、routes 与 deny 规则在创建引擎时编译为路径前缀树，请求时各只需一次遍历
+type Engine struct {
+	svc   *config.ServiceConfig
+	roles *config.RoleHierarchy
+
+	public *pathTrie[string]
+	routes *pathTrie[*config.RouteConfig]
+	denies *pathTrie[*config.DenyRule]
+}
+
+// New 创建服务的策略引擎，roles 为角色表（含继承关系）
+// - 路径模式已由 config.Validate 校验；万一无法解析，publicPaths 与 routes 忽略该条，deny 规则按 "/**" 处理（宁可多拒绝）
+func New(svc *config.ServiceConfig, roles *config.RoleHierarchy) *Engine {
+	e := &Engine{
+		svc:    svc,
+		roles:  roles,
+		public: newPathTrie[string](),
+		routes: newPathTrie[*config.RouteConfig](),
+		denies: newPathTrie[*config.DenyRule](),
+	}
+	for i, pattern := range svc.PublicPaths {
+		_ = e.public.insert(pattern, i, nil, pattern)
+	}
+	for i := range svc.Routes {
+		_ = e.routes.insert(svc.Routes[i].Path, i, svc.Routes[i].Methods, &svc.Routes[i])
+	}
+	for i := range svc.Deny {
+		rule := &svc.Deny[i]
+		if err := e.denies.insert(rule.Path, i, rule.Methods, rule); err != nil {
+			_ = e.denies.insert("/**", i, rule.Methods, rule)
+		}
+	}
+	return e
+}
+
+// Service 返回引擎所属的服务配置
+func (e *Engine) Service() *config.ServiceConfig { return e.svc }
+
+// Resolve 匹配相对服务前缀的子路径，不依赖调用方身份
+func (e *Engine) Resolve(subPath, method string) Resolution {
+	return e.resolve(subPath, method, false)
+}
+
+// ResolveTemplate 匹配 API 文档中的路径模板（如 "/posts/{id}"），"{id}" 段视为满足任意参数约束
+// - 用于聚合文档与配置导入，判断文档中的接口经网关访问时适用的策略
+func (e *Engine) ResolveTemplate(subPath, method string) Resolution {
+	return e.resolve(subPath, method, true)
+}
+
+func (e *Engine) resolve(subPath, method string, template bool) Resolution {
+	var (
+		denies    []*config.DenyRule
+		needsAuth bool
+	)
+	matched := find(e.denies, subPath, method, template)
+	sort.Slice(matched, func(i, j int) bool { return matched[i].entry.index < matched[j].entry.index })
+	for _, m := range matched {
+		rule := m.entry.value
+		enforced := e.svc.Enforced(rule.Enforce)
+		if enforced && len(rule.ExceptRoles) == 0 {
+			return Resolution{Access: AccessDenied, Rule: "deny " + rule.Path, Reason: rule.Reason}
+		}
+		denies = append(denies, rule)
+		needsAuth = needsAuth || enforced
+	}
+
+	res := e.resolveAllow(subPath, method, template)
+	res.denies = denies
+	res.needsAuth = needsAuth
+	return res
+}
+
+// resolveAllow 依次匹配 publicPaths、routes 与默认策略
+func (e *Engine) resolveAllow(subPath, method string, template bool) Resolution {
+	if m, ok := best(find(e.public, subPath, method, template)); ok {
+		return Resolution{Access: AccessPublic, Rule: "public " + m.entry.value}
+	}
+	if m, ok := best(find(e.routes, subPath, method, template)); ok {
+		return Resolution{Access: AccessRoute, Route: m.entry.value, Rule: RouteRule(m.entry.value), Params: paramMap(m.entry.names, m.params)}
+	}
+
+	switch policy := e.svc.EffectiveDefaultPolicy(); policy {
+	case config.DefaultPolicyPublic:
+		return Resolution{Access: AccessPublic, Rule: "default " + policy}
+	case config.DefaultPolicyAuthenticated:
+		return Resolution{Access: AccessAuthenticated, Rule: "default " + policy}
+	default:
+		return Resolution{Access: AccessNotFound, Rule: "default " + policy}
+	}
+}
+
+// AuthSchemes 返回认证该请求时应尝试的认证方式：命中路由时按路由配置，否则按服务配置
+func (e *Engine) AuthSchemes(res Resolution) []string {
+	return e.svc.AuthSchemes(res.Route)
+}
+
+// Authorize 对已认证（或无需认证）的请求作出最终判断
+// - p 为 nil 表示请求未经认证；试运行的带 exceptRoles 规则此时按访客身份判断
+// - 强制执行的拒绝优先于试运行的拒绝；只有试运行规则拒绝时返回 Allowed 且 Shadow 的结果
+func (e *Engine) Authorize(res Resolution, p *auth.Principal) Decision {
+	if res.Access == AccessDenied {
+		return reject(http.StatusForbidden, response.ErrCodeClientForbidden, "访问被策略拒绝", res.Rule, res.Reason)
+	}
+
+	if res.NeedsAuth() {
+		if p == nil {
+			return reject(http.StatusForbidden, response.ErrCodeClientForbidden, "权限不足 (无法获取身份)", res.Rule, "")
+		}
+		if p.Status == enums.StatusBlacklisted {
+			return reject(http.StatusForbidden, response.ErrCodeClientForbidden, "用户已被拉黑", res.Rule, "")
+		}
+	} else if p == nil {
+		p = auth.Anonymous()
+	}
+
+	var shadow *Decision
+	check := func(enforced bool, d Decision) bool {
+		if enforced {
+			return true
+		}
+		if shadow == nil {
+			d.Allowed, d.Shadow = true, true
+			shadow = &d
+		}
+		return false
+	}
+
+	for _, rule := range res.denies {
+		if len(rule.ExceptRoles) > 0 && e.exempt(p.Role, rule.ExceptRoles) {
+			continue
+		}
+		d := reject(http.StatusForbidden, response.ErrCodeClientForbidden, "访问被策略拒绝", "deny "+rule.Path, rule.Reason)
+		if check(e.svc.Enforced(rule.Enforce), d) {
+			return d
+		}
+	}
+
+	switch res.Access {
+	case AccessNotFound:
+		d := reject(http.StatusNotFound, response.ErrCodeClientResourceNotFound, "路径未定义或无权访问", res.Rule, "")
+		if check(e.svc.Enforced(nil), d) {
+			return d
+		}
+	case AccessAuthenticated:
+		if p.Scheme == config.AuthSchemeNone {
+			d := reject(http.StatusUnauthorized, response.ErrCodeClientUnauthorized, "需要登录", res.Rule, "")
+			if check(e.svc.Enforced(nil), d) {
+				return d
+			}
+		}
+	case AccessRoute:
+		// 角色要求与授权范围默认满足其一即可，scopes.mode 为 all 时都要满足
+		if !res.Route.Permits(e.roles, p.Role, p.Scopes) {
+			d := reject(http.StatusForbidden, response.ErrCodeClientForbidden, "权限不足", res.Rule, "")
+			if check(e.svc.Enforced(res.Route.Enforce), d) {
+				return d
+			}
+		}
+	}
+
+	if shadow != nil {
+		return *shadow
+	}
+	return Decision{Allowed: true, Rule: res.Rule}
+}
+
+// exempt 判断角色是否具备 names 中任一角色（含继承）
+func (e *Engine) exempt(role enums.UserRole, names []string) bool {
+	for _, name := range names {
+		if e.roles.Satisfies(role, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// RouteRule 返回路由的规则描述，如 "route GET,POST /posts/:id"
+func RouteRule(route *config.RouteConfig) string {
+	if len(route.Methods) == 0 {
+		return "route " + route.Path
+	}
+	return "route " + strings.Join(route.Methods, ",") + " " + route.Path
+}
+
+func reject(status, code int, msg, rule, reason string) Decision {
+	return Decision{Status: status, Code: code, Message: msg, Rule: rule, Reason: reason}
+}
+
+// methodAllowed 判断方法是否在列表中，列表为空或含 "*" 表示所有方法
+func methodAllowed(methods []string, method string) bool {
+	if len(methods) == 0 {
+		return true
+	}
+	for _, m := range methods {
+		if m == config.MethodWildcard || strings.EqualFold(m, method) {
+			return true
+		}
+	}
+	return false
+}
+
+// hasMethodWildcard 判断方法列表是否含 "*"
+func hasMethodWildcard(methods []string) bool {
+	for _, m := range methods {
+		if m == config.MethodWildcard {
+			return true
+		}
+	}
+	return false
+}
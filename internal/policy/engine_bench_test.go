@@ -0,0 +1,61 @@
+package policy
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/Xushengqwer/gateway/internal/config"
+)
+
+// benchService 构建含 n 条路由的服务：每组路由混合静态段、参数、受约束参数与通配段，另有 n/10 条 publicPaths 与 deny 规则
+func benchService(n int) *config.ServiceConfig {
+	svc := &config.ServiceConfig{Name: "bench", Prefix: "/api/v1/bench"}
+	for i := 0; i < n/4; i++ {
+		svc.Routes = append(svc.Routes,
+			config.RouteConfig{Path: fmt.Sprintf("/svc%d/items", i), Methods: []string{http.MethodGet}, MinRole: "user"},
+			config.RouteConfig{Path: fmt.Sprintf("/svc%d/items/:id", i), Methods: []string{http.MethodGet, http.MethodPut}, MinRole: "user"},
+			config.RouteConfig{Path: fmt.Sprintf("/svc%d/items/{id:[0-9]+}/comments", i), Methods: []string{http.MethodPost}, MinRole: "user"},
+			config.RouteConfig{Path: fmt.Sprintf("/svc%d/files/**", i), MinRole: "admin"},
+		)
+	}
+	for i := 0; i < max(n/10, 1); i++ {
+		svc.PublicPaths = append(svc.PublicPaths, fmt.Sprintf("/public%d/**", i))
+		svc.Deny = append(svc.Deny, config.DenyRule{Path: fmt.Sprintf("/internal%d/**", i)})
+	}
+	return svc
+}
+
+func BenchmarkEngineResolve(b *testing.B) {
+	roles, err := config.NewRoleHierarchy(config.DefaultRoles)
+	if err != nil {
+		b.Fatal(err)
+	}
+	for _, n := range []int{10, 100, 1000} {
+		engine := New(benchService(n), roles)
+		last := n/4 - 1
+		cases := []struct {
+			name, path, method string
+			want               Access
+		}{
+			{"route", fmt.Sprintf("/svc%d/items/42", last), http.MethodGet, AccessRoute},
+			{"constrained", fmt.Sprintf("/svc%d/items/42/comments", last), http.MethodPost, AccessRoute},
+			{"catchAll", fmt.Sprintf("/svc%d/files/a/b/c.txt", last), http.MethodGet, AccessRoute},
+			{"public", "/public0/assets/logo.png", http.MethodGet, AccessPublic},
+			{"deny", "/internal0/metrics", http.MethodGet, AccessDenied},
+			{"miss", fmt.Sprintf("/svc%d/unknown/42", last), http.MethodGet, AccessNotFound},
+			{"methodMiss", fmt.Sprintf("/svc%d/items/42", last), http.MethodDelete, AccessNotFound},
+		}
+		for _, tc := range cases {
+			if res := engine.Resolve(tc.path, tc.method); res.Access != tc.want {
+				b.Fatalf("routes=%d %s: %s %s 解析为 %v（%s），期望 %v", n, tc.name, tc.method, tc.path, res.Access, res.Rule, tc.want)
+			}
+			b.Run(fmt.Sprintf("routes=%d/%s", n, tc.name), func(b *testing.B) {
+				b.ReportAllocs()
+				for i := 0; i < b.N; i++ {
+					engine.Resolve(tc.path, tc.method)
+				}
+			})
+		}
+	}
+}
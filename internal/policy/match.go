@@ -0,0 +1,33 @@
+package policy
+
+import (
+	"strings"
+)
+
+// RelativePath 返回请求路径相对服务前缀的子路径（始终以 "/" 开头）
+func RelativePath(prefix, requestPath string) string {
+	subPath := strings.TrimPrefix(requestPath, prefix)
+	if !strings.HasPrefix(subPath, "/") {
+		subPath = "/" + subPath
+	}
+	return subPath
+}
+
+// Pattern 是编译后的单条路径模式，用于缓存规则等按配置顺序逐条匹配的场景，语法见 pathpattern 包
+type Pattern struct {
+	trie *pathTrie[struct{}]
+}
+
+// CompilePattern 编译路径模式
+func CompilePattern(pattern string) (*Pattern, error) {
+	t := newPathTrie[struct{}]()
+	if err := t.insert(pattern, 0, nil, struct{}{}); err != nil {
+		return nil, err
+	}
+	return &Pattern{trie: t}, nil
+}
+
+// Match 判断子路径是否匹配模式
+func (p *Pattern) Match(subPath string) bool {
+	return len(p.trie.match(subPath, "")) > 0
+}
@@ -0,0 +1,46 @@
+package policy
+
+import (
+	"github.com/Xushengqwer/gateway/internal/config"
+	"github.com/Xushengqwer/gateway/internal/pathpattern"
+)
+
+// ServiceIndex 按服务前缀组织的前缀树，一次遍历找出请求路径的最长匹配前缀
+// - 配置加载时由 config.ServiceGroups 构建，组内服务顺序保持匹配优先级
+type ServiceIndex struct {
+	root *trieNode[*config.ServiceGroup]
+}
+
+// NewServiceIndex 为服务分组构建前缀索引
+func NewServiceIndex(groups []config.ServiceGroup) *ServiceIndex {
+	root := &trieNode[*config.ServiceGroup]{}
+	for i := range groups {
+		// 前缀按字面逐段登记，不解析模式语法
+		n := root
+		for _, seg := range pathpattern.Split(groups[i].Prefix) {
+			n = n.staticChild(seg)
+		}
+		n.entries = append(n.entries, trieEntry[*config.ServiceGroup]{index: i, value: &groups[i]})
+	}
+	return &ServiceIndex{root: root}
+}
+
+// Lookup 返回路径命中的服务分组（最长前缀），前缀按整段匹配："/api/v1/user" 不匹配 "/api/v1/user-hub/x"
+func (x *ServiceIndex) Lookup(requestPath string) (*config.ServiceGroup, bool) {
+	var found *config.ServiceGroup
+	n := x.root
+	if len(n.entries) > 0 {
+		found = n.entries[0].value
+	}
+	for _, seg := range pathpattern.Split(requestPath) {
+		child, ok := n.static[seg]
+		if !ok {
+			break
+		}
+		n = child
+		if len(n.entries) > 0 {
+			found = n.entries[0].value
+		}
+	}
+	return found, found != nil
+}
@@ -0,0 +1,237 @@
+package policy
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/Xushengqwer/gateway/internal/pathpattern"
+)
+
+// pathTrie 是按路径段组织的前缀树，配置加载时构建，请求时一次遍历找出全部匹配的模式
+// - 静态段按段值索引；参数段按约束区分子节点（无约束的 ":id" / "{id}" / "*" 共用一个）
+// - "*path" 与 "**" 各有一个通配子节点；可选段在插入时展开为多条模式
+// - 遍历只进入与请求段相符的分支，代价与请求深度及同形模式数量相关，与模式总数基本无关
+type pathTrie[T any] struct {
+	root *trieNode[T]
+}
+
+type trieNode[T any] struct {
+	static   map[string]*trieNode[T]
+	params   []*paramChild[T]
+	catchAll *trieNode[T]
+	multi    *trieNode[T]
+	entries  []trieEntry[T]
+}
+
+// paramChild 是参数段子节点，key 为约束的原始写法（无约束为空串）
+type paramChild[T any] struct {
+	key  string
+	re   *regexp.Regexp
+	node *trieNode[T]
+}
+
+// trieEntry 是挂在模式末端节点上的一条配置
+type trieEntry[T any] struct {
+	index    int      // 配置中的序号，具体程度相同时序号小者优先
+	methods  []string // 适用的 HTTP 方法，为空或含 "*" 表示全部
+	explicit bool     // 是否显式列出了请求方法（不含通配）
+	names    []string // 模式中各参数段的参数名（匿名为空串），与匹配到的参数值一一对应
+	value    T
+}
+
+// trieMatch 是一次匹配的结果
+type trieMatch[T any] struct {
+	entry  *trieEntry[T]
+	rank   []pathpattern.Kind // 请求路径每一段被哪类模式段匹配，用于比较具体程度
+	params []string           // 按参数段顺序匹配到的值
+}
+
+func newPathTrie[T any]() *pathTrie[T] {
+	return &pathTrie[T]{root: &trieNode[T]{}}
+}
+
+// insert 登记路径模式；模式已由 config.Validate 校验，无法解析时返回错误且不登记
+func (t *pathTrie[T]) insert(pattern string, index int, methods []string, value T) error {
+	segs, err := pathpattern.Parse(pattern)
+	if err != nil {
+		return err
+	}
+	explicit := len(methods) > 0 && !hasMethodWildcard(methods)
+	for _, variant := range pathpattern.Expand(segs) {
+		n := t.root
+		var names []string
+		for _, seg := range variant {
+			switch seg.Kind {
+			case pathpattern.Multi:
+				if n.multi == nil {
+					n.multi = &trieNode[T]{}
+				}
+				n = n.multi
+			case pathpattern.CatchAll:
+				if n.catchAll == nil {
+					n.catchAll = &trieNode[T]{}
+				}
+				n = n.catchAll
+				names = append(names, seg.Name)
+			case pathpattern.Param, pathpattern.Constraint:
+				n = n.param(seg)
+				names = append(names, seg.Name)
+			default:
+				n = n.staticChild(seg.Value)
+			}
+		}
+		n.entries = append(n.entries, trieEntry[T]{index: index, methods: methods, explicit: explicit, names: names, value: value})
+	}
+	return nil
+}
+
+// staticChild 返回（必要时创建）静态段子节点
+func (n *trieNode[T]) staticChild(seg string) *trieNode[T] {
+	if n.static == nil {
+		n.static = make(map[string]*trieNode[T])
+	}
+	child, ok := n.static[seg]
+	if !ok {
+		child = &trieNode[T]{}
+		n.static[seg] = child
+	}
+	return child
+}
+
+// param 返回（必要时创建）与参数段约束相同的子节点
+func (n *trieNode[T]) param(seg pathpattern.Segment) *trieNode[T] {
+	for _, p := range n.params {
+		if p.key == seg.Value {
+			return p.node
+		}
+	}
+	p := &paramChild[T]{key: seg.Value, re: seg.Re, node: &trieNode[T]{}}
+	n.params = append(n.params, p)
+	return p.node
+}
+
+// match 返回与子路径及方法匹配的全部条目
+func (t *pathTrie[T]) match(subPath, method string) []trieMatch[T] {
+	return find(t, subPath, method, false)
+}
+
+// find 与 match 相同；template 为 true 时子路径是 API 文档中的路径模板，形如 "{id}" 的段匹配任意参数节点（忽略约束）
+func find[T any](t *pathTrie[T], subPath, method string, template bool) []trieMatch[T] {
+	w := &walker[T]{segs: pathpattern.Split(subPath), method: method, template: template}
+	w.walk(t.root, 0, make([]pathpattern.Kind, 0, len(w.segs)), nil)
+	return w.out
+}
+
+// best 返回最具体的匹配，规则见 pathpattern 包文档
+func best[T any](matches []trieMatch[T]) (trieMatch[T], bool) {
+	var best trieMatch[T]
+	found := false
+	for _, m := range matches {
+		if !found || m.moreSpecific(best) {
+			best, found = m, true
+		}
+	}
+	return best, found
+}
+
+// moreSpecific 判断 m 是否比 other 更具体：逐段比较段类型，再比较方法是否显式，最后比较配置序号
+func (m trieMatch[T]) moreSpecific(other trieMatch[T]) bool {
+	for i := range m.rank {
+		if m.rank[i] != other.rank[i] {
+			return m.rank[i] > other.rank[i]
+		}
+	}
+	if m.entry.explicit != other.entry.explicit {
+		return m.entry.explicit
+	}
+	return m.entry.index < other.entry.index
+}
+
+// walker 保存一次匹配的请求段与结果
+type walker[T any] struct {
+	segs     []string
+	method   string
+	template bool
+	out      []trieMatch[T]
+}
+
+// walk 从节点 n 开始匹配 segs[i:]，rank 与 params 为已匹配部分的段类型与参数
+// - rank 与 params 可能被兄弟分支共享，向下传递前先复制
+func (w *walker[T]) walk(n *trieNode[T], i int, rank []pathpattern.Kind, params []string) {
+	segs := w.segs
+	if n.multi != nil {
+		// "**" 可吞掉零个或多个段
+		next := rank
+		for j := i; j <= len(segs); j++ {
+			w.walk(n.multi, j, next, params)
+			next = appendKind(next, pathpattern.Multi)
+		}
+	}
+	if i == len(segs) {
+		n.collect(rank, params, w.method, &w.out)
+		return
+	}
+
+	seg := segs[i]
+	if child, ok := n.static[seg]; ok {
+		w.walk(child, i+1, appendKind(rank, pathpattern.Static), params)
+	}
+	if seg == "" {
+		return
+	}
+	placeholder := w.template && strings.HasPrefix(seg, "{") && strings.HasSuffix(seg, "}")
+	for _, p := range n.params {
+		kind := pathpattern.Param
+		if p.re != nil {
+			if !placeholder && !p.re.MatchString(seg) {
+				continue
+			}
+			kind = pathpattern.Constraint
+		}
+		w.walk(p.node, i+1, appendKind(rank, kind), appendParam(params, seg))
+	}
+	if n.catchAll != nil {
+		// "*path" 吞掉剩余的全部段（至少一段）
+		next := rank
+		for range segs[i:] {
+			next = appendKind(next, pathpattern.CatchAll)
+		}
+		n.catchAll.collect(next, appendParam(params, strings.Join(segs[i:], "/")), w.method, &w.out)
+	}
+}
+
+// collect 把节点上方法匹配的条目加入结果
+func (n *trieNode[T]) collect(rank []pathpattern.Kind, params []string, method string, out *[]trieMatch[T]) {
+	for k := range n.entries {
+		e := &n.entries[k]
+		if methodAllowed(e.methods, method) {
+			*out = append(*out, trieMatch[T]{entry: e, rank: rank, params: params})
+		}
+	}
+}
+
+func appendKind(rank []pathpattern.Kind, kind pathpattern.Kind) []pathpattern.Kind {
+	next := make([]pathpattern.Kind, len(rank), len(rank)+1)
+	copy(next, rank)
+	return append(next, kind)
+}
+
+func appendParam(params []string, value string) []string {
+	next := make([]string, len(params), len(params)+1)
+	copy(next, params)
+	return append(next, value)
+}
+
+// paramMap 把匹配到的参数值按名称组装为 map
+func paramMap(names, values []string) map[string]string {
+	if len(names) == 0 {
+		return nil
+	}
+	params := make(map[string]string, len(names))
+	for i, name := range names {
+		if name != "" && i < len(values) {
+			params[name] = values[i]
+		}
+	}
+	return params
+}
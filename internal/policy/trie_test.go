@@ -0,0 +1,92 @@
+package policy
+
+import (
+	"net/http"
+	"testing"
+)
+
+// TestTriePrecedence 按 pathpattern 包文档的优先级选择最具体的匹配
+func TestTriePrecedence(t *testing.T) {
+	type rule struct {
+		pattern string
+		methods []string
+	}
+	cases := []struct {
+		name   string
+		rules  []rule
+		path   string
+		method string
+		want   int // 期望命中的规则序号
+	}{
+		{
+			name:  "静态段优先于约束参数",
+			rules: []rule{{"/posts/:id<int>", nil}, {"/posts/42", nil}},
+			path:  "/posts/42", want: 1,
+		},
+		{
+			name:  "约束参数优先于参数",
+			rules: []rule{{"/posts/:id", nil}, {"/posts/{id:[0-9]+}", nil}},
+			path:  "/posts/42", want: 1,
+		},
+		{
+			name:  "约束不满足时退回参数",
+			rules: []rule{{"/posts/:id", nil}, {"/posts/{id:[0-9]+}", nil}},
+			path:  "/posts/abc", want: 0,
+		},
+		{
+			name:  "参数优先于具名通配",
+			rules: []rule{{"/files/*path", nil}, {"/files/*", nil}},
+			path:  "/files/a.txt", want: 1,
+		},
+		{
+			name:  "具名通配优先于多段通配",
+			rules: []rule{{"/files/**", nil}, {"/files/*path", nil}},
+			path:  "/files/a/b.txt", want: 1,
+		},
+		{
+			name:  "多段通配匹配零个段",
+			rules: []rule{{"/files/**", nil}, {"/files/*path", nil}},
+			path:  "/files", want: 0,
+		},
+		{
+			name:  "从左到右逐段比较",
+			rules: []rule{{"/:kind/comments", nil}, {"/posts/**", nil}},
+			path:  "/posts/comments", want: 1,
+		},
+		{
+			name:  "显式方法优先于方法通配",
+			rules: []rule{{"/posts/:id", []string{"*"}}, {"/posts/:id", []string{http.MethodGet}}},
+			path:  "/posts/42", method: http.MethodGet, want: 1,
+		},
+		{
+			name:  "同等具体时取靠前的规则",
+			rules: []rule{{"/posts/:id", nil}, {"/posts/{pid}", nil}},
+			path:  "/posts/42", want: 0,
+		},
+		{
+			name:  "可选段两种展开均可匹配",
+			rules: []rule{{"/posts/:id?", nil}},
+			path:  "/posts", want: 0,
+		},
+	}
+	for _, tc := range cases {
+		trie := newPathTrie[string]()
+		for i, r := range tc.rules {
+			if err := trie.insert(r.pattern, i, r.methods, r.pattern); err != nil {
+				t.Fatalf("%s: 登记 %q 失败: %v", tc.name, r.pattern, err)
+			}
+		}
+		method := tc.method
+		if method == "" {
+			method = http.MethodGet
+		}
+		m, ok := best(trie.match(tc.path, method))
+		if !ok {
+			t.Errorf("%s: %s 没有匹配", tc.name, tc.path)
+			continue
+		}
+		if m.entry.index != tc.want {
+			t.Errorf("%s: %s 匹配第 %d 条规则 %q，期望第 %d 条", tc.name, tc.path, m.entry.index, m.entry.value, tc.want)
+		}
+	}
+}
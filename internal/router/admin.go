@@ -0,0 +1,141 @@
+package router
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+
+	"github.com/Xushengqwer/gateway/internal/config"
+	sharedCore "github.com/Xushengqwer/go-common/core"
+	"github.com/Xushengqwer/go-common/response"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// setWeightsRequest 是批量调整版本权重的请求体
+type setWeightsRequest struct {
+	Weights map[string]int `json:"weights" binding:"required"`
+}
+
+// setWeightRequest 是调整单个版本权重的请求体
+type setWeightRequest struct {
+	Weight *int `json:"weight" binding:"required"`
+}
+
+// setupAdminRoutes 注册网关管理 API，所有接口都要求携带管理令牌
+func setupAdminRoutes(r *gin.Engine, cfg *config.GatewayConfig, logger *sharedCore.ZapLogger, state *runtimeState) {
+	admin := r.Group(cfg.Admin.PathPrefix(), adminAuthMiddleware(cfg.Admin.Token))
+	splitters := state.splitters
+
+	// --- 流量分配 (金丝雀 / 蓝绿) ---
+	admin.GET("/services", func(c *gin.Context) {
+		result := make(map[string][]VersionStatus)
+		for _, name := range splitters.names() {
+			ts, _ := splitters.get(name)
+			result[name] = ts.Snapshot()
+		}
+		response.RespondSuccess(c, result)
+	})
+
+	admin.GET("/services/:name/versions", func(c *gin.Context) {
+		ts, ok := splitters.get(c.Param("name"))
+		if !ok {
+			response.RespondError(c, http.StatusNotFound, response.ErrCodeClientResourceNotFound, "服务未找到")
+			return
+		}
+		response.RespondSuccess(c, ts.Snapshot())
+	})
+
+	admin.PUT("/services/:name/versions", func(c *gin.Context) {
+		var req setWeightsRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			response.RespondError(c, http.StatusBadRequest, response.ErrCodeClientInvalidInput, "请求体无效: "+err.Error())
+			return
+		}
+		updateWeights(c, splitters, logger, req.Weights)
+	})
+
+	admin.PUT("/services/:name/versions/:version/weight", func(c *gin.Context) {
+		var req setWeightRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			response.RespondError(c, http.StatusBadRequest, response.ErrCodeClientInvalidInput, "请求体无效: "+err.Error())
+			return
+		}
+		updateWeights(c, splitters, logger, map[string]int{c.Param("version"): *req.Weight})
+	})
+
+	// --- 路由及其元数据 (名称 / 描述 / 标签 / metadata) ---
+	routes := listRoutes(cfg)
+	admin.GET("/routes", func(c *gin.Context) {
+		response.RespondSuccess(c, routes)
+	})
+
+	// --- 流量镜像 ---
+	admin.GET("/mirrors", func(c *gin.Context) {
+		response.RespondSuccess(c, state.mirrors.stats())
+	})
+
+	// --- 访问策略试运行 (enforce: false) ---
+	admin.GET("/policy/shadow", func(c *gin.Context) {
+		response.RespondSuccess(c, state.shadow.stats())
+	})
+	admin.DELETE("/policy/shadow", func(c *gin.Context) {
+		response.RespondSuccess(c, gin.H{"cleared": state.shadow.reset()})
+	})
+
+	// --- 响应缓存 ---
+	if state.cache != nil {
+		admin.GET("/cache", func(c *gin.Context) {
+			response.RespondSuccess(c, state.cache.Stats())
+		})
+		// DELETE /cache?service=post-service&prefix=/api/v1/post/hot-posts ，参数均可省略
+		admin.DELETE("/cache", func(c *gin.Context) {
+			service, prefix := c.Query("service"), c.Query("prefix")
+			removed := state.cache.Purge(service, prefix)
+			logger.Info("通过管理 API 清除响应缓存",
+				zap.String("serviceName", service),
+				zap.String("prefix", prefix),
+				zap.Int("removed", removed),
+				zap.String("clientIP", c.ClientIP()))
+			response.RespondSuccess(c, gin.H{"removed": removed})
+		})
+	}
+
+	// --- API Key ---
+	if state.apiKeys != nil {
+		setupAPIKeyRoutes(admin, cfg, logger, state.apiKeys)
+	}
+}
+
+// updateWeights 调整指定服务的版本权重并返回最新快照
+func updateWeights(c *gin.Context, splitters *splitterRegistry, logger *sharedCore.ZapLogger, weights map[string]int) {
+	name := c.Param("name")
+	ts, ok := splitters.get(name)
+	if !ok {
+		response.RespondError(c, http.StatusNotFound, response.ErrCodeClientResourceNotFound, "服务未找到")
+		return
+	}
+	if err := ts.SetWeights(weights); err != nil {
+		response.RespondError(c, http.StatusBadRequest, response.ErrCodeClientInvalidInput, err.Error())
+		return
+	}
+	logger.Info("通过管理 API 调整版本权重",
+		zap.String("serviceName", name),
+		zap.Any("weights", weights),
+		zap.String("clientIP", c.ClientIP()))
+	response.RespondSuccess(c, ts.Snapshot())
+}
+
+// adminAuthMiddleware 校验 "Authorization: Bearer <token>" 形式的管理令牌
+func adminAuthMiddleware(token string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		provided, found := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if !found || subtle.ConstantTimeCompare([]byte(provided), []byte(token)) != 1 {
+			response.RespondError(c, http.StatusUnauthorized, response.ErrCodeClientUnauthorized, "管理令牌无效")
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,112 @@
+package router
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/Xushengqwer/gateway/internal/apikey"
+	"github.com/Xushengqwer/gateway/internal/config"
+	sharedCore "github.com/Xushengqwer/go-common/core"
+	"github.com/Xushengqwer/go-common/models/enums"
+	"github.com/Xushengqwer/go-common/response"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// createAPIKeyRequest 是创建 API Key 的请求体
+type createAPIKeyRequest struct {
+	Owner     string          `json:"owner" binding:"required"`
+	Role      *enums.UserRole `json:"role" binding:"required"`
+	Scopes    []string        `json:"scopes"`
+	Services  []string        `json:"services"`
+	Plan      string          `json:"plan"`
+	ExpiresAt *time.Time      `json:"expiresAt"`
+}
+
+// issuedAPIKey 是创建或轮换 API Key 的响应，明文 Key 只在此返回一次
+type issuedAPIKey struct {
+	Key    apikey.Key `json:"key"`
+	APIKey string     `json:"apiKey"`
+}
+
+// setupAPIKeyRoutes 注册 API Key 管理接口
+// - POST /apikeys 创建；GET /apikeys 列表；POST /apikeys/:id/rotate?grace=24h 轮换；DELETE /apikeys/:id 吊销
+func setupAPIKeyRoutes(admin *gin.RouterGroup, cfg *config.GatewayConfig, logger *sharedCore.ZapLogger, store *apikey.Store) {
+	admin.GET("/apikeys", func(c *gin.Context) {
+		response.RespondSuccess(c, store.List())
+	})
+
+	admin.POST("/apikeys", func(c *gin.Context) {
+		var req createAPIKeyRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			response.RespondError(c, http.StatusBadRequest, response.ErrCodeClientInvalidInput, "请求体无效: "+err.Error())
+			return
+		}
+		if err := cfg.CheckAPIKeyGrant(req.Plan, req.Services); err != nil {
+			response.RespondError(c, http.StatusBadRequest, response.ErrCodeClientInvalidInput, err.Error())
+			return
+		}
+		key, plaintext, err := store.Create(apikey.CreateOptions{
+			Owner:     req.Owner,
+			Role:      *req.Role,
+			Scopes:    req.Scopes,
+			Services:  req.Services,
+			Plan:      req.Plan,
+			ExpiresAt: req.ExpiresAt,
+		})
+		if err != nil {
+			response.RespondError(c, http.StatusInternalServerError, response.ErrCodeServerInternal, err.Error())
+			return
+		}
+		logger.Info("通过管理 API 创建 API Key",
+			zap.String("keyID", key.ID),
+			zap.String("owner", key.Owner),
+			zap.String("clientIP", c.ClientIP()))
+		response.RespondSuccess(c, issuedAPIKey{Key: key, APIKey: plaintext})
+	})
+
+	admin.POST("/apikeys/:id/rotate", func(c *gin.Context) {
+		var grace time.Duration
+		if v := c.Query("grace"); v != "" {
+			d, err := time.ParseDuration(v)
+			if err != nil || d < 0 {
+				response.RespondError(c, http.StatusBadRequest, response.ErrCodeClientInvalidInput, "grace 不是有效的时长")
+				return
+			}
+			grace = d
+		}
+		key, plaintext, err := store.Rotate(c.Param("id"), grace)
+		if err != nil {
+			respondAPIKeyError(c, err)
+			return
+		}
+		logger.Info("通过管理 API 轮换 API Key",
+			zap.String("keyID", key.ID),
+			zap.Duration("grace", grace),
+			zap.String("clientIP", c.ClientIP()))
+		response.RespondSuccess(c, issuedAPIKey{Key: key, APIKey: plaintext})
+	})
+
+	admin.DELETE("/apikeys/:id", func(c *gin.Context) {
+		key, err := store.Revoke(c.Param("id"))
+		if err != nil {
+			respondAPIKeyError(c, err)
+			return
+		}
+		logger.Info("通过管理 API 吊销 API Key",
+			zap.String("keyID", key.ID),
+			zap.String("clientIP", c.ClientIP()))
+		response.RespondSuccess(c, key)
+	})
+}
+
+// respondAPIKeyError 把存储返回的错误映射为管理 API 响应
+func respondAPIKeyError(c *gin.Context, err error) {
+	if errors.Is(err, apikey.ErrNotFound) {
+		response.RespondError(c, http.StatusNotFound, response.ErrCodeClientResourceNotFound, err.Error())
+		return
+	}
+	response.RespondError(c, http.StatusBadRequest, response.ErrCodeClientInvalidInput, err.Error())
+}
@@ -0,0 +1,116 @@
+package router
+
+import (
+	"github.com/Xushengqwer/gateway/internal/audit"
+	"github.com/Xushengqwer/gateway/internal/auth"
+	"github.com/Xushengqwer/gateway/internal/config"
+	mymiddleware "github.com/Xushengqwer/gateway/internal/middleware"
+	"github.com/Xushengqwer/gateway/internal/policy"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// auditor 为单个服务生成审计记录
+type auditor struct {
+	log     *audit.Logger // 未启用审计时为 nil
+	roles   *config.RoleHierarchy
+	service string
+}
+
+// decision 记录策略引擎的判断结果
+func (a *auditor) decision(c *gin.Context, d policy.Decision) {
+	if a.log == nil {
+		return
+	}
+	e := a.event(c, d.Rule)
+	switch {
+	case d.Shadow:
+		e.Decision = audit.DecisionShadowDeny
+	case d.Allowed:
+		e.Decision = audit.DecisionAllow
+	default:
+		e.Decision = audit.DecisionDeny
+	}
+	if !d.Allowed || d.Shadow {
+		e.Status = d.Status
+		e.Reason = d.Message
+		if d.Reason != "" {
+			e.Reason += ": " + d.Reason
+		}
+	}
+	a.log.Record(e)
+}
+
+// permission 记录权限中间件的判断结果
+func (a *auditor) permission(c *gin.Context, rule string) {
+	if a.log == nil {
+		return
+	}
+	if v, ok := c.Get(mymiddleware.PolicyDecisionKey); ok {
+		if d, ok := v.(policy.Decision); ok {
+			a.decision(c, d)
+			return
+		}
+	}
+	a.decision(c, policy.Decision{Allowed: !c.IsAborted(), Status: c.Writer.Status(), Rule: rule})
+}
+
+// authFailure 记录认证链拒绝的请求
+func (a *auditor) authFailure(c *gin.Context, rule string) {
+	if a.log == nil {
+		return
+	}
+	e := a.event(c, rule)
+	e.Decision = audit.DecisionDeny
+	e.Status = c.Writer.Status()
+	if f, ok := auth.FailureFrom(c); ok {
+		e.Reason = "认证失败: " + f.Message
+	}
+	a.log.Record(e)
+}
+
+// event 从请求上下文填充审计记录的公共字段
+func (a *auditor) event(c *gin.Context, rule string) audit.Event {
+	e := audit.Event{
+		TraceID:  traceIDFrom(c),
+		Service:  a.service,
+		ClientIP: c.ClientIP(),
+		Method:   c.Request.Method,
+		Path:     c.Request.URL.Path,
+		Rule:     rule,
+	}
+	if route := resolvedRoute(c); route != nil {
+		e.Route, e.Tags, e.Metadata = route.Name, route.Tags, route.Metadata
+	}
+	if p, ok := auth.PrincipalFrom(c); ok {
+		e.UserID = p.ID
+		e.Role = a.roles.Name(p.Role)
+		e.Scheme = p.Scheme
+		e.Platform = p.Platform
+	}
+	return e
+}
+
+// resolvedRoute 返回本次请求命中的路由，未命中路由时返回 nil
+func resolvedRoute(c *gin.Context) *config.RouteConfig {
+	if v, ok := c.Get(mymiddleware.PolicyResolutionKey); ok {
+		if res, ok := v.(policy.Resolution); ok {
+			return res.Route
+		}
+	}
+	return nil
+}
+
+// traceIDFrom 优先取 OpenTelemetry 的 Trace ID，未启用追踪时退回 X-Request-ID
+func traceIDFrom(c *gin.Context) string {
+	if sc := trace.SpanContextFromContext(c.Request.Context()); sc.HasTraceID() {
+		return sc.TraceID().String()
+	}
+	if v, ok := c.Get("traceID"); ok {
+		if s, ok := v.(string); ok && s != "" {
+			return s
+		}
+	}
+	return c.GetHeader("X-Request-ID")
+}
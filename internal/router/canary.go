@@ -0,0 +1,242 @@
+package router
+
+import (
+	"fmt"
+	"hash/fnv"
+	"math/rand/v2"
+	"net/http"
+	"sort"
+	"sync"
+
+	"github.com/Xushengqwer/gateway/internal/config"
+	"github.com/Xushengqwer/go-common/constants"
+	"github.com/Xushengqwer/go-common/models/enums"
+
+	"github.com/gin-gonic/gin"
+)
+
+// defaultVersionName 是未配置多版本时服务唯一上游的版本名
+const defaultVersionName = "default"
+
+// versionCookiePrefix 是匿名请求保持版本一致所用 Cookie 的名称前缀，后接服务名
+const versionCookiePrefix = "gw_version_"
+
+// weightBuckets 是按权重分配时的桶数：用户哈希到固定的 [0, weightBuckets) 区间，再与各版本的累计权重比例比较
+// - 桶与权重总和无关，调高灰度版本的权重只会把位于阈值附近的用户从稳定版本移入灰度版本，其余用户保持不变
+const weightBuckets = 10000
+
+// versionUpstream 是参与流量分配的一个上游版本
+type versionUpstream struct {
+	*upstream
+	weight int
+	match  *config.VersionMatch
+}
+
+// VersionStatus 描述一个上游版本的当前状态，供管理 API 输出
+type VersionStatus struct {
+	Name   string `json:"name"`
+	Target string `json:"target"`
+	Weight int    `json:"weight"`
+}
+
+// trafficSplitter 在服务的多个上游版本之间分配流量
+// - 先按 VersionMatch 定向，再按权重分配；已认证用户按用户 ID 哈希固定版本
+// - 权重可在运行时通过 SetWeights 调整，无需重载配置
+type trafficSplitter struct {
+	service  string
+	sticky   bool
+	mu       sync.RWMutex
+	versions []*versionUpstream
+}
+
+// newTrafficSplitter 创建流量分配器，versions 按配置顺序排列
+func newTrafficSplitter(service string, sticky bool, versions []*versionUpstream) *trafficSplitter {
+	return &trafficSplitter{service: service, sticky: sticky, versions: versions}
+}
+
+// pickForContext 为当前请求选择上游版本，必须在认证之后调用以便使用用户信息
+// - 需要保持匿名用户版本一致时直接在响应中写入 Cookie
+func (ts *trafficSplitter) pickForContext(c *gin.Context) *upstream {
+	userID := c.GetString(string(constants.UserIDKey))
+	role, hasRole := c.Get(string(constants.RoleKey))
+	target, cookie := ts.pick(c.Request, userID, role, hasRole)
+	if cookie != nil {
+		http.SetCookie(c.Writer, cookie)
+	}
+	return target
+}
+
+// pick 按定向规则与权重选择上游版本
+// - 返回的 Cookie 非 nil 时调用方应写入响应，以保持匿名用户的版本一致
+func (ts *trafficSplitter) pick(req *http.Request, userID string, role any, hasRole bool) (*upstream, *http.Cookie) {
+	ts.mu.RLock()
+	defer ts.mu.RUnlock()
+
+	if len(ts.versions) == 1 {
+		return ts.versions[0].upstream, nil
+	}
+
+	// 1. 定向规则优先，按配置顺序取第一个命中的版本
+	for _, v := range ts.versions {
+		if v.match != nil && ts.versionMatches(v.match, req, userID, role, hasRole) {
+			return v.upstream, nil
+		}
+	}
+
+	// 2. 按权重分配
+	total := 0
+	for _, v := range ts.versions {
+		total += v.weight
+	}
+	if total == 0 {
+		return ts.versions[0].upstream, nil
+	}
+
+	cookieName := versionCookiePrefix + ts.service
+	var bucket int
+	switch {
+	case userID != "":
+		bucket = int(hashKey(ts.service, userID) % weightBuckets)
+	case ts.sticky:
+		if cookie, err := req.Cookie(cookieName); err == nil {
+			for _, v := range ts.versions {
+				if v.name == cookie.Value && v.weight > 0 {
+					return v.upstream, nil
+				}
+			}
+		}
+		bucket = rand.IntN(weightBuckets)
+	default:
+		bucket = rand.IntN(weightBuckets)
+	}
+
+	// 第一个累计权重比例超过 bucket/weightBuckets 的版本；权重为 0 的版本不会被选中
+	chosen := ts.versions[0]
+	cumulative := 0
+	for _, v := range ts.versions {
+		cumulative += v.weight
+		if bucket*total < cumulative*weightBuckets {
+			chosen = v
+			break
+		}
+	}
+
+	if ts.sticky && userID == "" {
+		return chosen.upstream, &http.Cookie{Name: cookieName, Value: chosen.name, Path: "/", HttpOnly: true}
+	}
+	return chosen.upstream, nil
+}
+
+// versionMatches 判断请求是否满足版本的定向条件，任一条件满足即命中
+func (ts *trafficSplitter) versionMatches(m *config.VersionMatch, req *http.Request, userID string, role any, hasRole bool) bool {
+	for _, f := range m.Headers {
+		if values, ok := req.Header[http.CanonicalHeaderKey(f.Name)]; ok && matchFieldValue(f.Value, values) {
+			return true
+		}
+	}
+	for _, f := range m.Cookies {
+		if cookie, err := req.Cookie(f.Name); err == nil && matchFieldValue(f.Value, []string{cookie.Value}) {
+			return true
+		}
+	}
+	if userID != "" {
+		for _, id := range m.UserIDs {
+			if id == userID {
+				return true
+			}
+		}
+		if m.UserPercent > 0 && int(hashKey(ts.service+"/percent", userID)%100) < m.UserPercent {
+			return true
+		}
+	}
+	if r, ok := role.(enums.UserRole); hasRole && ok {
+		for _, allowed := range m.Roles {
+			if allowed == r {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// SetWeights 原子地更新一个或多个版本的权重，未知版本或权重超出 0-config.MaxVersionWeight 时不做任何修改
+func (ts *trafficSplitter) SetWeights(weights map[string]int) error {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+
+	byName := make(map[string]*versionUpstream, len(ts.versions))
+	for _, v := range ts.versions {
+		byName[v.name] = v
+	}
+	for name, w := range weights {
+		if _, ok := byName[name]; !ok {
+			return fmt.Errorf("服务 %s 不存在版本 %s", ts.service, name)
+		}
+		if w < 0 || w > config.MaxVersionWeight {
+			return fmt.Errorf("版本 %s 的权重必须在 0-%d 之间", name, config.MaxVersionWeight)
+		}
+	}
+	for name, w := range weights {
+		byName[name].weight = w
+	}
+	return nil
+}
+
+// Snapshot 返回各版本当前的权重快照
+func (ts *trafficSplitter) Snapshot() []VersionStatus {
+	ts.mu.RLock()
+	defer ts.mu.RUnlock()
+
+	statuses := make([]VersionStatus, 0, len(ts.versions))
+	for _, v := range ts.versions {
+		statuses = append(statuses, VersionStatus{Name: v.name, Target: v.target.String(), Weight: v.weight})
+	}
+	return statuses
+}
+
+// splitterRegistry 按服务名登记流量分配器，供管理 API 调整权重
+type splitterRegistry struct {
+	mu        sync.RWMutex
+	splitters map[string]*trafficSplitter
+}
+
+// newSplitterRegistry 创建空的分配器登记表
+func newSplitterRegistry() *splitterRegistry {
+	return &splitterRegistry{splitters: make(map[string]*trafficSplitter)}
+}
+
+// register 登记服务的流量分配器
+func (sr *splitterRegistry) register(ts *trafficSplitter) {
+	sr.mu.Lock()
+	defer sr.mu.Unlock()
+	sr.splitters[ts.service] = ts
+}
+
+// get 按服务名查找流量分配器
+func (sr *splitterRegistry) get(service string) (*trafficSplitter, bool) {
+	sr.mu.RLock()
+	defer sr.mu.RUnlock()
+	ts, ok := sr.splitters[service]
+	return ts, ok
+}
+
+// names 返回已登记的服务名（有序）
+func (sr *splitterRegistry) names() []string {
+	sr.mu.RLock()
+	defer sr.mu.RUnlock()
+	names := make([]string, 0, len(sr.splitters))
+	for name := range sr.splitters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// hashKey 计算 service 与 key 组合的 FNV-1a 哈希，用于稳定的用户分桶
+func hashKey(service, key string) uint32 {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(service))
+	_, _ = h.Write([]byte{0})
+	_, _ = h.Write([]byte(key))
+	return h.Sum32()
+}
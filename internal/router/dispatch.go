@@ -0,0 +1,113 @@
+package router
+
+import (
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/Xushengqwer/gateway/internal/config"
+	sharedCore "github.com/Xushengqwer/go-common/core"
+	"github.com/Xushengqwer/go-common/response"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// serviceCandidate 是同一前缀下的一个候选服务及其处理函数
+type serviceCandidate struct {
+	svc     config.ServiceConfig
+	handler gin.HandlerFunc
+}
+
+// dispatchByMatch 在共享同一前缀的服务之间按匹配条件选择目标服务
+// - candidates 必须已按 config.ServiceGroups 的优先级排序，取第一个条件全部满足的服务
+// - 只有一个且无匹配条件的服务时直接返回其处理函数，行为与按前缀路由一致
+func dispatchByMatch(prefix string, candidates []serviceCandidate, logger *sharedCore.ZapLogger) gin.HandlerFunc {
+	if len(candidates) == 1 && candidates[0].svc.Match.IsEmpty() {
+		return candidates[0].handler
+	}
+
+	return func(c *gin.Context) {
+		for _, candidate := range candidates {
+			if requestMatches(candidate.svc.Match, c.Request) {
+				candidate.handler(c)
+				return
+			}
+		}
+
+		logger.Warn("请求未匹配前缀下任何服务的匹配条件",
+			zap.String("prefix", prefix),
+			zap.String("host", c.Request.Host),
+			zap.String("path", c.Request.URL.Path))
+		response.RespondError(c, http.StatusNotFound, response.ErrCodeClientResourceNotFound, "服务未找到")
+		c.Abort()
+	}
+}
+
+// requestMatches 判断请求是否满足服务的全部匹配条件，未配置条件视为匹配
+func requestMatches(m *config.MatchConfig, req *http.Request) bool {
+	if m.IsEmpty() {
+		return true
+	}
+
+	if len(m.Hosts) > 0 {
+		host := requestHost(req)
+		hostMatched := false
+		for _, pattern := range m.Hosts {
+			if matchHost(strings.ToLower(pattern), host) {
+				hostMatched = true
+				break
+			}
+		}
+		if !hostMatched {
+			return false
+		}
+	}
+
+	for _, f := range m.Headers {
+		values, ok := req.Header[http.CanonicalHeaderKey(f.Name)]
+		if !ok || !matchFieldValue(f.Value, values) {
+			return false
+		}
+	}
+
+	query := req.URL.Query()
+	for _, f := range m.Query {
+		values, ok := query[f.Name]
+		if !ok || !matchFieldValue(f.Value, values) {
+			return false
+		}
+	}
+	return true
+}
+
+// requestHost 返回去掉端口并转为小写的请求 Host
+func requestHost(req *http.Request) string {
+	host := req.Host
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	return strings.ToLower(host)
+}
+
+// matchHost 支持精确匹配与 "*.example.com" 形式的单级子域名通配
+func matchHost(pattern, host string) bool {
+	if suffix, ok := strings.CutPrefix(pattern, "*."); ok {
+		label, rest, found := strings.Cut(host, ".")
+		return found && label != "" && rest == suffix
+	}
+	return pattern == host
+}
+
+// matchFieldValue 期望值为空或 "*" 时只要求存在，否则任一取值精确匹配即可
+func matchFieldValue(expected string, values []string) bool {
+	if expected == "" || expected == "*" {
+		return true
+	}
+	for _, v := range values {
+		if v == expected {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,249 @@
+package router
+
+import (
+	"context"
+	"encoding/json"
+	"html/template"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Xushengqwer/gateway/internal/config"
+	"github.com/Xushengqwer/gateway/internal/openapi"
+	"github.com/Xushengqwer/gateway/internal/policy"
+	sharedCore "github.com/Xushengqwer/go-common/core"
+	"github.com/Xushengqwer/go-common/response"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// docSource 是一个服务的文档来源及最近一次加载成功的文档
+type docSource struct {
+	svc      *config.ServiceConfig
+	engine   *policy.Engine
+	location string       // 文件路径或完整地址
+	client   *http.Client // 拉取远程文档使用的客户端，文件来源为 nil
+
+	doc *openapi.Document
+	err error
+}
+
+// docsServer 定期加载各服务的文档并缓存合并结果
+// - 某个服务的文档加载失败时沿用上次加载成功的版本，从未成功时记录在 x-gateway-sources 中
+type docsServer struct {
+	cfg     *config.DocsConfig
+	opts    openapi.MergeOptions
+	sources []*docSource
+	logger  *sharedCore.ZapLogger
+
+	mu   sync.RWMutex
+	spec []byte // 合并后的 JSON，首次加载完成前为 nil
+
+	done     chan struct{} // 关闭后停止后台刷新
+	stopOnce sync.Once
+}
+
+// setupDocsRoutes 注册聚合文档与 Swagger UI，并在后台按 refreshInterval 重新加载各服务文档
+func setupDocsRoutes(r *gin.Engine, cfg *config.GatewayConfig, logger *sharedCore.ZapLogger, state *runtimeState) {
+	docs := cfg.Docs
+	ds := &docsServer{
+		cfg:    docs,
+		logger: logger,
+		done:   make(chan struct{}),
+		opts: openapi.MergeOptions{
+			Title:   docs.Title,
+			Version: docs.Version,
+			Roles:   state.roles,
+		},
+	}
+	if ds.opts.Title == "" {
+		ds.opts.Title = "API Gateway"
+	}
+	if ds.opts.Version == "" {
+		ds.opts.Version = "1.0"
+	}
+	if cfg.APIKeys != nil {
+		ds.opts.APIKeyHeader = cfg.APIKeys.HeaderName()
+	}
+
+	for i := range cfg.Services {
+		svc := &cfg.Services[i]
+		if svc.OpenAPI == nil {
+			continue
+		}
+		src, err := newDocSource(svc, docs.Timeout(), cfg.TracerConfig.Enabled)
+		if err != nil {
+			logger.Fatal("构建服务文档来源失败",
+				zap.String("serviceName", svc.Name),
+				zap.Error(err))
+		}
+		src.engine = policy.New(svc, state.roles)
+		ds.sources = append(ds.sources, src)
+	}
+
+	state.docs = ds
+	go ds.run(docs.Refresh())
+
+	prefix := docs.PathPrefix()
+	r.GET(prefix, ds.serveUI)
+	r.GET(prefix+"/", ds.serveUI)
+	r.GET(prefix+"/openapi.json", ds.serveSpec)
+}
+
+// run 立即加载一次文档，之后按 interval 刷新，直到 stop 被调用
+func (ds *docsServer) run(interval time.Duration) {
+	ds.refresh()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			ds.refresh()
+		case <-ds.done:
+			return
+		}
+	}
+}
+
+// stop 停止后台刷新，可重复调用；正在进行的刷新会完成后再退出
+func (ds *docsServer) stop() {
+	ds.stopOnce.Do(func() { close(ds.done) })
+}
+
+// newDocSource 解析服务的文档来源：以 "/" 开头的 url 相对服务上游（多版本时取第一个版本），经服务自身的 Transport 拉取
+func newDocSource(svc *config.ServiceConfig, timeout time.Duration, tracing bool) (*docSource, error) {
+	src := &docSource{svc: svc}
+	if svc.OpenAPI.File != "" {
+		src.location = svc.OpenAPI.File
+		return src, nil
+	}
+	if !strings.HasPrefix(svc.OpenAPI.URL, "/") {
+		src.location = svc.OpenAPI.URL
+		src.client = &http.Client{Timeout: timeout}
+		return src, nil
+	}
+
+	scheme, host, port, serviceName, namespace := svc.Scheme, svc.Host, svc.Port, svc.ServiceName, svc.Namespace
+	if len(svc.Versions) > 0 {
+		v := svc.Versions[0]
+		scheme, host, port, serviceName, namespace = v.Scheme, v.Host, v.Port, v.ServiceName, v.Namespace
+	}
+	target, err := buildTargetURL(scheme, host, port, serviceName, namespace)
+	if err != nil {
+		return nil, err
+	}
+	transport, err := newServiceTransport(*svc, tracing)
+	if err != nil {
+		return nil, err
+	}
+	src.location = target.String() + svc.OpenAPI.URL
+	src.client = &http.Client{Transport: transport, Timeout: timeout}
+	return src, nil
+}
+
+// refresh 并发加载全部文档并重新合并
+func (ds *docsServer) refresh() {
+	var wg sync.WaitGroup
+	for _, src := range ds.sources {
+		wg.Add(1)
+		go func(src *docSource) {
+			defer wg.Done()
+			ctx, cancel := context.WithTimeout(context.Background(), ds.cfg.Timeout())
+			defer cancel()
+			doc, err := openapi.Load(ctx, src.client, src.location)
+			if err != nil {
+				src.err = err
+				ds.logger.Warn("加载服务文档失败",
+					zap.String("serviceName", src.svc.Name),
+					zap.String("location", src.location),
+					zap.Bool("usingPrevious", src.doc != nil),
+					zap.Error(err))
+				return
+			}
+			src.doc, src.err = doc, nil
+		}(src)
+	}
+	wg.Wait()
+
+	sources := make([]openapi.Source, 0, len(ds.sources))
+	for _, src := range ds.sources {
+		s := openapi.Source{
+			Service:  src.svc,
+			Engine:   src.engine,
+			Location: src.location,
+			BasePath: src.svc.OpenAPI.BasePath,
+			Doc:      src.doc,
+		}
+		if src.doc == nil {
+			s.Err = src.err
+		}
+		sources = append(sources, s)
+	}
+	merged, statuses := openapi.Merge(sources, ds.opts)
+	spec, err := json.Marshal(merged)
+	if err != nil {
+		ds.logger.Error("序列化聚合文档失败", zap.Error(err))
+		return
+	}
+	for _, st := range statuses {
+		if st.Error != "" || len(st.Warnings) > 0 {
+			ds.logger.Warn("服务文档未完整合并",
+				zap.String("serviceName", st.Service),
+				zap.Int("operations", st.Operations),
+				zap.Int("skipped", st.Skipped),
+				zap.Strings("warnings", st.Warnings),
+				zap.String("error", st.Error))
+		}
+	}
+
+	ds.mu.Lock()
+	ds.spec = spec
+	ds.mu.Unlock()
+}
+
+// serveSpec 返回合并后的文档
+func (ds *docsServer) serveSpec(c *gin.Context) {
+	ds.mu.RLock()
+	spec := ds.spec
+	ds.mu.RUnlock()
+	if spec == nil {
+		response.RespondError(c, http.StatusServiceUnavailable, response.ErrCodeServerInternal, "文档尚未加载完成")
+		return
+	}
+	c.Data(http.StatusOK, "application/json; charset=utf-8", spec)
+}
+
+// serveUI 返回加载合并文档的 Swagger UI 页面
+func (ds *docsServer) serveUI(c *gin.Context) {
+	var page strings.Builder
+	err := swaggerUIPage.Execute(&page, map[string]string{
+		"Title":  ds.opts.Title,
+		"Assets": strings.TrimSuffix(ds.cfg.UIAssets(), "/"),
+		"Spec":   ds.cfg.PathPrefix() + "/openapi.json",
+	})
+	if err != nil {
+		ds.logger.Error("渲染 Swagger UI 失败", zap.Error(err))
+		response.RespondError(c, http.StatusInternalServerError, response.ErrCodeServerInternal, "渲染文档页面失败")
+		return
+	}
+	c.Data(http.StatusOK, "text/html; charset=utf-8", []byte(page.String()))
+}
+
+var swaggerUIPage = template.Must(template.New("swagger-ui").Parse(`<!DOCTYPE html>
+<html lang="zh-CN">
+<head>
+  <meta charset="utf-8">
+  <title>{{.Title}}</title>
+  <link rel="stylesheet" href="{{.Assets}}/swagger-ui.css">
+</head>
+<body>
+  <div id="swagger-ui"></div>
+  <script src="{{.Assets}}/swagger-ui-bundle.js"></script>
+  <script>
+    window.ui = SwaggerUIBundle({url: {{.Spec}}, dom_id: "#swagger-ui", deepLinking: true});
+  </script>
+</body>
+</html>
+`))
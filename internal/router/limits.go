@@ -0,0 +1,129 @@
+package router
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/Xushengqwer/gateway/internal/config"
+	mymiddleware "github.com/Xushengqwer/gateway/internal/middleware"
+	"github.com/Xushengqwer/go-common/response"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// enforceBodyLimit 检查请求体的大小与类型，不满足时直接响应 413/415 并返回 false
+// - Content-Length 已知且超限时立即拒绝
+// - 长度未知（chunked）时包装为 MaxBytesReader，转发途中超限由反向代理的 ErrorHandler 返回 413
+func (rt *serviceRuntime) enforceBodyLimit(c *gin.Context, limit config.BodyLimit) bool {
+	req := c.Request
+	if req.Body == nil || req.Body == http.NoBody || req.ContentLength == 0 {
+		return true
+	}
+
+	if len(limit.AllowedContentTypes) > 0 {
+		mediaType, _, err := mime.ParseMediaType(req.Header.Get("Content-Type"))
+		if err != nil || !mediaTypeAllowed(mediaType, limit.AllowedContentTypes) {
+			rt.rejectBody(c, http.StatusUnsupportedMediaType, mymiddleware.ErrCodeClientUnsupportedMedia,
+				"不支持的请求体类型", zap.String("contentType", req.Header.Get("Content-Type")))
+			return false
+		}
+	}
+
+	if limit.MaxBodyBytes > 0 {
+		if req.ContentLength > limit.MaxBodyBytes {
+			rt.rejectBody(c, http.StatusRequestEntityTooLarge, mymiddleware.ErrCodeClientBodyTooLarge,
+				"请求体过大", zap.Int64("contentLength", req.ContentLength), zap.Int64("maxBodyBytes", limit.MaxBodyBytes))
+			return false
+		}
+		req.Body = http.MaxBytesReader(c.Writer, req.Body, limit.MaxBodyBytes)
+	}
+	return true
+}
+
+// enforcePartTypes 检查 multipart 请求中文件部分的 Content-Type，需在请求体解压之后调用
+// - 需要完整读取请求体（大小已受 maxBodyBytes 约束），检查完成后放回请求中供转发使用
+func (rt *serviceRuntime) enforcePartTypes(c *gin.Context, limit config.BodyLimit) bool {
+	req := c.Request
+	if len(limit.AllowedPartTypes) == 0 || req.Body == nil || req.Body == http.NoBody {
+		return true
+	}
+	mediaType, params, err := mime.ParseMediaType(req.Header.Get("Content-Type"))
+	if err != nil || !strings.HasPrefix(mediaType, "multipart/") {
+		return true
+	}
+
+	data, err := io.ReadAll(req.Body)
+	_ = req.Body.Close()
+	if err != nil {
+		var maxErr *http.MaxBytesError
+		if errors.As(err, &maxErr) {
+			rt.rejectBody(c, http.StatusRequestEntityTooLarge, mymiddleware.ErrCodeClientBodyTooLarge,
+				"请求体过大", zap.Int64("maxBodyBytes", maxErr.Limit))
+		} else {
+			rt.rejectBody(c, http.StatusBadRequest, response.ErrCodeClientInvalidInput, "读取请求体失败", zap.Error(err))
+		}
+		return false
+	}
+
+	mr := multipart.NewReader(bytes.NewReader(data), params["boundary"])
+	for {
+		part, err := mr.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			rt.rejectBody(c, http.StatusBadRequest, response.ErrCodeClientInvalidInput, "multipart 请求体格式错误", zap.Error(err))
+			return false
+		}
+		if part.FileName() == "" {
+			continue
+		}
+		partType := part.Header.Get("Content-Type")
+		if partType == "" {
+			partType = "application/octet-stream"
+		}
+		partMedia, _, _ := mime.ParseMediaType(partType)
+		if !mediaTypeAllowed(partMedia, limit.AllowedPartTypes) {
+			rt.rejectBody(c, http.StatusUnsupportedMediaType, mymiddleware.ErrCodeClientUnsupportedMedia,
+				"不支持的上传文件类型", zap.String("fileName", part.FileName()), zap.String("partType", partType))
+			return false
+		}
+	}
+
+	req.Body = io.NopCloser(bytes.NewReader(data))
+	req.ContentLength = int64(len(data))
+	req.Header.Set("Content-Length", strconv.Itoa(len(data)))
+	return true
+}
+
+// rejectBody 记录并响应被拒绝的请求体
+func (rt *serviceRuntime) rejectBody(c *gin.Context, status, code int, message string, fields ...zap.Field) {
+	rt.logger.Warn("拒绝请求体: "+message, append([]zap.Field{
+		zap.String("serviceName", rt.name),
+		zap.String("path", c.Request.URL.Path),
+		zap.String("clientIP", c.ClientIP()),
+	}, fields...)...)
+	response.RespondError(c, status, code, message)
+	c.Abort()
+}
+
+// mediaTypeAllowed 判断媒体类型是否在允许列表中，支持 "image/*" 与 "*/*" 通配
+func mediaTypeAllowed(mediaType string, allowed []string) bool {
+	for _, a := range allowed {
+		a = strings.ToLower(strings.TrimSpace(a))
+		switch {
+		case a == "*/*", a == mediaType:
+			return true
+		case strings.HasSuffix(a, "/*") && strings.HasPrefix(mediaType, strings.TrimSuffix(a, "*")):
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,303 @@
+package router
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"net/url"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/Xushengqwer/gateway/internal/config"
+	sharedCore "github.com/Xushengqwer/go-common/core"
+
+	"go.uber.org/zap"
+)
+
+const (
+	defaultMirrorMaxBodyBytes = 1 << 20         // 默认最大镜像请求体 1MiB
+	defaultMirrorTimeout      = 5 * time.Second // 默认镜像请求超时
+	maxInflightMirrors        = 256             // 每个镜像目标允许同时进行的镜像请求数，超出直接丢弃
+)
+
+// MirrorStats 是一个镜像目标的累计统计，供管理 API 输出
+type MirrorStats struct {
+	Service        string `json:"service"`
+	Route          string `json:"route,omitempty"` // 路由级镜像所属路由的名称
+	Target         string `json:"target"`
+	Sent           int64  `json:"sent"`           // 已发出的镜像请求数
+	Failed         int64  `json:"failed"`         // 镜像请求失败数（网络错误或超时）
+	StatusMismatch int64  `json:"statusMismatch"` // 与主上游状态码不一致的次数
+	Dropped        int64  `json:"dropped"`        // 因请求体过大或并发已满而放弃镜像的次数
+}
+
+// hopHeaders 是复制镜像请求时需要去掉的逐跳请求头
+var hopHeaders = []string{"Connection", "Keep-Alive", "Proxy-Connection", "Te", "Trailer", "Transfer-Encoding", "Upgrade"}
+
+// mirrorSnapshot 是在主请求转发前复制下来的镜像请求内容
+type mirrorSnapshot struct {
+	method string
+	uri    string
+	header http.Header
+	body   []byte
+}
+
+// mirror 负责把采样到的请求异步复制到镜像上游
+type mirror struct {
+	service  string
+	route    string // 路由级镜像所属路由的名称，服务级镜像为空
+	target   *url.URL
+	percent  float64
+	maxBody  int64
+	client   *http.Client
+	logger   *sharedCore.ZapLogger
+	inflight chan struct{}
+
+	sent, failed, mismatch, dropped atomic.Int64
+}
+
+// newMirror 根据镜像配置创建镜像器
+func newMirror(service string, cfg *config.MirrorConfig, transport http.RoundTripper, logger *sharedCore.ZapLogger) (*mirror, error) {
+	target, err := buildTargetURL(cfg.Scheme, cfg.Host, cfg.Port, cfg.ServiceName, cfg.Namespace)
+	if err != nil {
+		return nil, err
+	}
+	maxBody := cfg.MaxBodyBytes
+	if maxBody == 0 {
+		maxBody = defaultMirrorMaxBodyBytes
+	}
+	timeout := cfg.Timeout
+	if timeout == 0 {
+		timeout = defaultMirrorTimeout
+	}
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+
+	return &mirror{
+		service:  service,
+		target:   target,
+		percent:  cfg.Percent,
+		maxBody:  maxBody,
+		client:   &http.Client{Transport: transport, Timeout: timeout},
+		logger:   logger,
+		inflight: make(chan struct{}, maxInflightMirrors),
+	}, nil
+}
+
+// capture 按比例采样并复制请求，未采样或无法镜像时返回 nil
+// - 必须在路径改写之后、主请求转发之前调用；读取的请求体会被放回，主请求不受影响
+func (m *mirror) capture(req *http.Request) *mirrorSnapshot {
+	if rand.Float64()*100 >= m.percent {
+		return nil
+	}
+	if req.ContentLength > m.maxBody {
+		m.dropped.Add(1)
+		return nil
+	}
+
+	var body []byte
+	if req.Body != nil && req.Body != http.NoBody {
+		buf, err := io.ReadAll(io.LimitReader(req.Body, m.maxBody+1))
+		if int64(len(buf)) > m.maxBody || err != nil {
+			// 请求体超限（或读取出错）：放弃镜像，把已读部分拼回主请求
+			req.Body = readCloser{Reader: io.MultiReader(bytes.NewReader(buf), req.Body), Closer: req.Body}
+			m.dropped.Add(1)
+			return nil
+		}
+		req.Body = readCloser{Reader: bytes.NewReader(buf), Closer: req.Body}
+		body = buf
+	}
+
+	header := req.Header.Clone()
+	for _, h := range hopHeaders {
+		header.Del(h)
+	}
+	return &mirrorSnapshot{
+		method: req.Method,
+		uri:    req.URL.RequestURI(),
+		header: header,
+		body:   body,
+	}
+}
+
+// dispatch 在后台发送镜像请求并记录与主上游的差异，不阻塞调用方
+func (m *mirror) dispatch(snap *mirrorSnapshot, primaryStatus int, primaryLatency time.Duration) {
+	select {
+	case m.inflight <- struct{}{}:
+	default:
+		m.dropped.Add(1)
+		return
+	}
+
+	go func() {
+		defer func() {
+			<-m.inflight
+			if p := recover(); p != nil {
+				m.logger.Error("镜像请求发生 panic", zap.String("serviceName", m.service), zap.Any("panic", p))
+			}
+		}()
+		m.send(snap, primaryStatus, primaryLatency)
+	}()
+}
+
+// send 同步发送镜像请求并丢弃响应体
+func (m *mirror) send(snap *mirrorSnapshot, primaryStatus int, primaryLatency time.Duration) {
+	req, err := http.NewRequestWithContext(context.Background(), snap.method, m.target.String()+snap.uri, bytes.NewReader(snap.body))
+	if err != nil {
+		m.failed.Add(1)
+		m.logger.Warn("构建镜像请求失败", zap.String("serviceName", m.service), zap.Error(err))
+		return
+	}
+	req.Header = snap.header
+	req.Header.Set("X-Gateway-Mirror", "1")
+	req.Host = m.target.Host
+
+	m.sent.Add(1)
+	start := time.Now()
+	resp, err := m.client.Do(req)
+	latency := time.Since(start)
+	if err != nil {
+		m.failed.Add(1)
+		m.logger.Warn("镜像请求失败",
+			zap.String("serviceName", m.service),
+			zap.String("mirrorTarget", m.target.String()),
+			zap.String("uri", snap.uri),
+			zap.Duration("primaryLatency", primaryLatency),
+			zap.Error(err))
+		return
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+	_ = resp.Body.Close()
+
+	fields := []zap.Field{
+		zap.String("serviceName", m.service),
+		zap.String("mirrorTarget", m.target.String()),
+		zap.String("method", snap.method),
+		zap.String("uri", snap.uri),
+		zap.Int("primaryStatus", primaryStatus),
+		zap.Int("mirrorStatus", resp.StatusCode),
+		zap.Duration("primaryLatency", primaryLatency),
+		zap.Duration("mirrorLatency", latency),
+		zap.Duration("latencyDelta", latency-primaryLatency),
+	}
+	if resp.StatusCode != primaryStatus {
+		m.mismatch.Add(1)
+		m.logger.Warn("镜像响应状态码与主上游不一致", fields...)
+		return
+	}
+	m.logger.Debug("镜像请求完成", fields...)
+}
+
+// stats 返回当前累计统计
+func (m *mirror) stats() MirrorStats {
+	return MirrorStats{
+		Service:        m.service,
+		Route:          m.route,
+		Target:         m.target.String(),
+		Sent:           m.sent.Load(),
+		Failed:         m.failed.Load(),
+		StatusMismatch: m.mismatch.Load(),
+		Dropped:        m.dropped.Load(),
+	}
+}
+
+// readCloser 组合读取源与原始请求体的关闭器
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// serviceMirrors 保存一个服务的服务级与路由级镜像器
+// - 路由级镜像以 *config.MirrorConfig 指针为键，策略引擎返回的路由与服务配置中的路由持有同一指针
+type serviceMirrors struct {
+	service *mirror
+	routes  map[*config.MirrorConfig]*mirror
+}
+
+// newServiceMirrors 为服务及其路由创建镜像器，全部未配置时返回 nil
+func newServiceMirrors(svc config.ServiceConfig, transport http.RoundTripper, logger *sharedCore.ZapLogger) (*serviceMirrors, error) {
+	sm := &serviceMirrors{routes: make(map[*config.MirrorConfig]*mirror)}
+	if svc.Mirror != nil {
+		m, err := newMirror(svc.Name, svc.Mirror, transport, logger)
+		if err != nil {
+			return nil, err
+		}
+		sm.service = m
+	}
+	for _, route := range svc.Routes {
+		if route.Mirror == nil {
+			continue
+		}
+		m, err := newMirror(svc.Name, route.Mirror, transport, logger)
+		if err != nil {
+			return nil, err
+		}
+		m.route = route.Name
+		sm.routes[route.Mirror] = m
+	}
+	if sm.service == nil && len(sm.routes) == 0 {
+		return nil, nil
+	}
+	return sm, nil
+}
+
+// forRoute 返回适用于命中路由的镜像器，路由级配置优先
+func (sm *serviceMirrors) forRoute(route *config.RouteConfig) *mirror {
+	if sm == nil {
+		return nil
+	}
+	if route != nil && route.Mirror != nil {
+		return sm.routes[route.Mirror]
+	}
+	return sm.service
+}
+
+// all 返回服务下全部镜像器
+func (sm *serviceMirrors) all() []*mirror {
+	if sm == nil {
+		return nil
+	}
+	var mirrors []*mirror
+	if sm.service != nil {
+		mirrors = append(mirrors, sm.service)
+	}
+	for _, m := range sm.routes {
+		mirrors = append(mirrors, m)
+	}
+	return mirrors
+}
+
+// mirrorRegistry 登记全部镜像器，供管理 API 查看统计
+type mirrorRegistry struct {
+	mu      sync.RWMutex
+	mirrors []*mirror
+}
+
+// register 登记服务的镜像器
+func (mr *mirrorRegistry) register(sm *serviceMirrors) {
+	mr.mu.Lock()
+	defer mr.mu.Unlock()
+	mr.mirrors = append(mr.mirrors, sm.all()...)
+}
+
+// stats 返回全部镜像目标的统计（按服务名、目标排序）
+func (mr *mirrorRegistry) stats() []MirrorStats {
+	mr.mu.RLock()
+	defer mr.mu.RUnlock()
+	result := make([]MirrorStats, 0, len(mr.mirrors))
+	for _, m := range mr.mirrors {
+		result = append(result, m.stats())
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Service != result[j].Service {
+			return result[i].Service < result[j].Service
+		}
+		return result[i].Target < result[j].Target
+	})
+	return result
+}
@@ -0,0 +1,145 @@
+package router
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"slices"
+
+	"github.com/Xushengqwer/gateway/internal/auth"
+	"github.com/Xushengqwer/gateway/internal/config"
+	mymiddleware "github.com/Xushengqwer/gateway/internal/middleware"
+	"github.com/Xushengqwer/gateway/internal/policy"
+	"github.com/Xushengqwer/go-common/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 离线策略判断的结果
+const (
+	OutcomePublic   = "public"   // 无需认证即放行
+	OutcomeAllowed  = "allowed"  // 认证后放行
+	OutcomeDenied   = "denied"   // 401 / 403
+	OutcomeNotFound = "notfound" // 404
+)
+
+// PolicyResult 是一次离线策略判断的结果
+type PolicyResult struct {
+	Outcome string
+	Status  int    // 拒绝时的状态码
+	Service string // 选中的服务，未选中时为空
+	Rule    string // 作出判断的规则
+	Route   string // 命中路由的名称（未命中或未命名时为空）
+	Message string // 拒绝消息
+	Shadow  bool   // 试运行规则本应拒绝，实际放行
+}
+
+// PolicyTester 不启动网关、不访问上游，按运行时相同的逻辑判断请求的访问结果
+// - 服务选择与 dispatchByMatch 一致；策略判断使用 policy.Engine 与 PermissionMiddleware
+// - 身份直接由调用方给出，不校验凭证；nil 表示请求未携带任何凭证
+// - 身份的认证方式必须在路由接受的 auth 列表中；JWT 身份带有平台时按请求的 X-Platform / 路径校验平台，与运行时一致
+type PolicyTester struct {
+	services    *policy.ServiceIndex
+	engines     map[string]*policy.Engine
+	permHandler gin.HandlerFunc
+}
+
+// NewPolicyTester 为已校验的配置创建离线策略判断器
+func NewPolicyTester(cfg *config.GatewayConfig) (*PolicyTester, error) {
+	roles, err := cfg.RoleHierarchy()
+	if err != nil {
+		return nil, err
+	}
+	groups := cfg.ServiceGroups()
+	pt := &PolicyTester{
+		services:    policy.NewServiceIndex(groups),
+		engines:     make(map[string]*policy.Engine),
+		permHandler: mymiddleware.PermissionMiddleware(cfg),
+	}
+	for gi := range groups {
+		for si := range groups[gi].Services {
+			svc := &groups[gi].Services[si]
+			pt.engines[svc.Name] = policy.New(svc, roles)
+		}
+	}
+	return pt, nil
+}
+
+// Evaluate 判断请求在给定身份下的访问结果
+func (pt *PolicyTester) Evaluate(req *http.Request, principal *auth.Principal) PolicyResult {
+	svc := pt.selectService(req)
+	if svc == nil {
+		return PolicyResult{Outcome: OutcomeNotFound, Status: http.StatusNotFound, Message: "服务未找到"}
+	}
+	engine := pt.engines[svc.Name]
+	res := engine.Resolve(policy.RelativePath(svc.Prefix, req.URL.Path), req.Method)
+
+	var routeName string
+	if res.Route != nil {
+		routeName = res.Route.Name
+	}
+
+	if !res.NeedsAuth() {
+		return decisionResult(svc.Name, routeName, engine.Authorize(res, nil), OutcomePublic)
+	}
+
+	schemes := engine.AuthSchemes(res)
+	if principal == nil {
+		if !slices.Contains(schemes, config.AuthSchemeNone) {
+			return PolicyResult{Outcome: OutcomeDenied, Status: http.StatusUnauthorized, Service: svc.Name, Rule: res.Rule, Route: routeName, Message: "未携带凭证"}
+		}
+		principal = auth.Anonymous()
+	} else if !slices.Contains(schemes, principal.Scheme) {
+		// 运行时认证链只尝试路由接受的方式，其他方式的凭证等同于未携带
+		return PolicyResult{Outcome: OutcomeDenied, Status: http.StatusUnauthorized, Service: svc.Name, Rule: res.Rule, Route: routeName,
+			Message: "路由不接受 " + principal.Scheme + " 认证"}
+	} else if principal.Scheme == config.AuthSchemeJWT && principal.Platform != "" {
+		if err := mymiddleware.CheckPlatform(req, principal.Platform); err != nil {
+			return PolicyResult{Outcome: OutcomeDenied, Status: err.Status, Service: svc.Name, Rule: res.Rule, Route: routeName, Message: err.Message}
+		}
+	}
+
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = req
+	c.Set(mymiddleware.ServiceConfigKey, svc)
+	c.Set(mymiddleware.PolicyResolutionKey, res)
+	auth.SetPrincipal(c, principal)
+	pt.permHandler(c)
+
+	d, _ := c.Get(mymiddleware.PolicyDecisionKey)
+	decision, ok := d.(policy.Decision)
+	if !ok {
+		return PolicyResult{Outcome: OutcomeDenied, Status: c.Writer.Status(), Service: svc.Name, Rule: res.Rule, Route: routeName}
+	}
+	return decisionResult(svc.Name, routeName, decision, OutcomeAllowed)
+}
+
+// selectService 按前缀（最长匹配）与服务匹配条件选择服务，与运行时路由一致
+func (pt *PolicyTester) selectService(req *http.Request) *config.ServiceConfig {
+	best, ok := pt.services.Lookup(req.URL.Path)
+	if !ok {
+		return nil
+	}
+	for i := range best.Services {
+		if requestMatches(best.Services[i].Match, req) {
+			return &best.Services[i]
+		}
+	}
+	return nil
+}
+
+// decisionResult 把策略判断转换为离线结果，allowed 为放行时使用的结果
+func decisionResult(service, route string, d policy.Decision, allowed string) PolicyResult {
+	r := PolicyResult{Service: service, Rule: d.Rule, Route: route, Shadow: d.Shadow, Message: d.Message}
+	switch {
+	case d.Allowed:
+		r.Outcome = allowed
+		if d.Shadow {
+			r.Status = d.Status
+		}
+	case d.Code == response.ErrCodeClientResourceNotFound:
+		r.Outcome, r.Status = OutcomeNotFound, d.Status
+	default:
+		r.Outcome, r.Status = OutcomeDenied, d.Status
+	}
+	return r
+}
@@ -1,16 +1,16 @@
 package router
 
 import (
-	"fmt"
+	"io"
 	"net/http"
-	"net/http/httputil"
-	"net/url"
-	"strings"
 
+	"github.com/Xushengqwer/gateway/internal/auth"
+	"github.com/Xushengqwer/gateway/internal/compress"
 	"github.com/Xushengqwer/gateway/internal/config"
 	"github.com/Xushengqwer/gateway/internal/constant"
 	gatewayCore "github.com/Xushengqwer/gateway/internal/core"
 	mymiddleware "github.com/Xushengqwer/gateway/internal/middleware"
+	"github.com/Xushengqwer/gateway/internal/policy"
 	sharedCore "github.com/Xushengqwer/go-common/core"
 	sharedMiddleware "github.com/Xushengqwer/go-common/middleware"
 	"github.com/Xushengqwer/go-common/response" // <-- 确保已导入
@@ -21,8 +21,8 @@ import (
 )
 
 // SetupRouter 设置网关的所有路由和全局中间件。
-// (函数保持不变)
-func SetupRouter(r *gin.Engine, cfg *config.GatewayConfig, logger *sharedCore.ZapLogger, jwtUtil gatewayCore.JWTUtilityInterface, otelTransport http.RoundTripper) {
+// - 返回值持有审计日志文件等运行时资源，调用方在服务器关闭后（或用重新构建的路由替换本路由后）调用 Close 释放
+func SetupRouter(r *gin.Engine, cfg *config.GatewayConfig, logger *sharedCore.ZapLogger, jwtUtil gatewayCore.JWTUtilityInterface, otelTransport http.RoundTripper) io.Closer {
 	logger.Info("开始设置网关路由及全局中间件...")
 
 	// --- 1. 应用全局中间件 (按执行顺序排列) ---
@@ -36,6 +36,7 @@ func SetupRouter(r *gin.Engine, cfg *config.GatewayConfig, logger *sharedCore.Za
 	} else {
 		logger.Warn("无法获取底层的 *zap.Logger，跳过 RequestLoggerMiddleware 注册")
 	}
+	r.Use(skipGlobalTimeout(cfg)) // 配置了自身超时的服务跳过全局超时
 	r.Use(sharedMiddleware.RequestTimeoutMiddleware(logger, cfg.Server.RequestTimeout))
 	if cfg.RateLimitConfig != nil {
 		r.Use(mymiddleware.RateLimitMiddleware(logger, cfg.RateLimitConfig))
@@ -45,6 +46,16 @@ func SetupRouter(r *gin.Engine, cfg *config.GatewayConfig, logger *sharedCore.Za
 	}
 	r.Use(mymiddleware.CorsMiddleware(cfg.Cors))
 	logger.Info("CORS 中间件已启用。")
+	if cfg.TLS != nil && cfg.TLS.Enabled && cfg.TLS.HSTS != nil {
+		r.Use(mymiddleware.HSTSMiddleware(*cfg.TLS.HSTS))
+		logger.Info("HSTS 中间件已启用。", zap.Duration("maxAge", cfg.TLS.HSTS.MaxAge))
+	}
+	if cfg.Limits.MaxHeaderBytes > 0 || cfg.Limits.MaxHeaderCount > 0 {
+		r.Use(mymiddleware.HeaderLimitMiddleware(logger, cfg.Limits))
+		logger.Info("请求头大小限制中间件已启用。",
+			zap.Int("maxHeaderBytes", cfg.Limits.MaxHeaderBytes),
+			zap.Int("maxHeaderCount", cfg.Limits.MaxHeaderCount))
+	}
 
 	// --- 2. 设置健康检查路由 ---
 	r.GET("/health", func(c *gin.Context) {
@@ -53,159 +64,172 @@ func SetupRouter(r *gin.Engine, cfg *config.GatewayConfig, logger *sharedCore.Za
 	logger.Info("健康检查路由 /health 已注册。")
 
 	// --- 3. 设置代理路由和特定中间件 ---
-	setupProxyRoutesInternal(r, cfg, logger, jwtUtil, otelTransport)
+	state := newRuntimeState(cfg, logger, jwtUtil)
+	setupProxyRoutesInternal(r, cfg, logger, otelTransport, state)
 	logger.Info("所有代理路由已设置完成。")
-}
-
-// matchPublicPath 检查请求子路径是否匹配给定的公共路径模式 (支持参数)
-// (函数保持不变)
-func matchPublicPath(publicPathPattern string, requestSubPath string) bool {
-	patternSegments := strings.Split(strings.Trim(publicPathPattern, "/"), "/")
-	requestSegments := strings.Split(strings.Trim(requestSubPath, "/"), "/")
-
-	isPatternRoot := publicPathPattern == "/" || (len(patternSegments) == 1 && patternSegments[0] == "")
-	isRequestRoot := requestSubPath == "/" || (len(requestSegments) == 1 && requestSegments[0] == "")
 
-	if isPatternRoot && isRequestRoot {
-		return true
+	// --- 4. 设置管理 API ---
+	if cfg.Admin.Enabled {
+		setupAdminRoutes(r, cfg, logger, state)
+		logger.Info("管理 API 已启用。", zap.String("prefix", cfg.Admin.PathPrefix()))
 	}
 
-	if len(patternSegments) != len(requestSegments) {
-		return false
-	}
-
-	for i, pSegment := range patternSegments {
-		rSegment := requestSegments[i]
-		if strings.HasPrefix(pSegment, ":") || strings.HasPrefix(pSegment, "{") {
-			if rSegment == "" {
-				return false
-			}
-			continue
-		}
-		if pSegment != rSegment {
-			return false
-		}
+	// --- 5. 设置聚合 API 文档 ---
+	if cfg.Docs != nil && cfg.Docs.Enabled {
+		setupDocsRoutes(r, cfg, logger, state)
+		logger.Info("聚合 API 文档已启用。", zap.String("path", cfg.Docs.PathPrefix()))
 	}
-	return true
+	return state
 }
 
 // setupProxyRoutesInternal 根据配置文件中的服务列表，设置反向代理路由。
-// (函数保持不变, 除了 handler 创建部分)
-func setupProxyRoutesInternal(r *gin.Engine, cfg *config.GatewayConfig, logger *sharedCore.ZapLogger, jwtUtil gatewayCore.JWTUtilityInterface, otelTransport http.RoundTripper) {
-
-	for _, svc := range cfg.Services {
-		serviceConfig := svc // 避免闭包问题
-
-		var targetHost string
-		var port int
-		scheme := serviceConfig.Scheme
-		if scheme == "" {
-			scheme = "http"
+// - 共享同一 Prefix 的服务注册为一个 Gin 路由，由 dispatchByMatch 按 Host/请求头/查询参数选择目标服务
+func setupProxyRoutesInternal(r *gin.Engine, cfg *config.GatewayConfig, logger *sharedCore.ZapLogger, otelTransport http.RoundTripper, state *runtimeState) {
+
+	for _, group := range cfg.ServiceGroups() {
+		candidates := make([]serviceCandidate, 0, len(group.Services))
+		for _, svc := range group.Services {
+			candidates = append(candidates, serviceCandidate{
+				svc:     svc,
+				handler: buildServiceHandler(svc, cfg, logger, otelTransport, state),
+			})
+			logger.Info("服务匹配优先级",
+				zap.String("prefix", group.Prefix),
+				zap.String("serviceName", svc.Name),
+				zap.String("match", svc.Match.Describe()),
+				zap.Int("specificity", svc.Match.Specificity()))
 		}
+		handler := dispatchByMatch(group.Prefix, candidates, logger)
 
-		if serviceConfig.ServiceName != "" {
-			namespace := serviceConfig.Namespace
-			if namespace == "" {
-				namespace = "default"
-			}
-			targetHost = fmt.Sprintf("%s.%s.svc.cluster.local", serviceConfig.ServiceName, namespace)
-			port = serviceConfig.Port
-			if port == 0 {
-				port = 80
-			}
-		} else {
-			if serviceConfig.Host == "" || serviceConfig.Port == 0 {
-				logger.Fatal("非K8s模式下服务配置无效：Host 或 Port 未指定",
-					zap.String("serviceName", serviceConfig.Name))
-			}
-			targetHost = serviceConfig.Host
-			port = serviceConfig.Port
+		proxyPath := group.Prefix + "/*action"
+		logger.Info("为服务前缀注册代理处理器",
+			zap.String("ginRoutePath", proxyPath),
+			zap.Int("serviceCount", len(candidates)))
+
+		r.Any(proxyPath, handler)
+		if group.Prefix != "" {
+			r.Any(group.Prefix, handler)
+			logger.Info("同时为服务根前缀注册处理器",
+				zap.String("exactPrefixPath", group.Prefix))
 		}
+	}
+}
 
-		targetURL, err := url.Parse(fmt.Sprintf("%s://%s:%d", scheme, targetHost, port))
+// buildServiceHandler 为单个服务构建上游反向代理及其 Gin 处理函数
+// - 配置了 Versions 时为每个版本各建一个反向代理，并登记流量分配器供管理 API 调整
+func buildServiceHandler(serviceConfig config.ServiceConfig, cfg *config.GatewayConfig, logger *sharedCore.ZapLogger, otelTransport http.RoundTripper, state *runtimeState) gin.HandlerFunc {
+	transport, err := newServiceTransport(serviceConfig, cfg.TracerConfig.Enabled)
+	if err != nil {
+		logger.Fatal("构建上游 Transport 失败",
+			zap.String("serviceName", serviceConfig.Name),
+			zap.Error(err))
+	}
+	var versions []*versionUpstream
+	if len(serviceConfig.Versions) == 0 {
+		targetURL, err := buildTargetURL(serviceConfig.Scheme, serviceConfig.Host, serviceConfig.Port, serviceConfig.ServiceName, serviceConfig.Namespace)
 		if err != nil {
 			logger.Fatal("解析目标服务URL失败",
 				zap.String("serviceName", serviceConfig.Name),
-				zap.String("targetHost", targetHost),
-				zap.Int("port", port),
 				zap.Error(err))
 		}
-		logger.Info("构建目标服务URL成功",
-			zap.String("serviceName", serviceConfig.Name),
-			zap.String("targetURL", targetURL.String()))
-
-		proxy := httputil.NewSingleHostReverseProxy(targetURL)
-		if otelTransport != nil {
-			proxy.Transport = otelTransport
-		}
-
-		proxy.Director = func(req *http.Request) {
-			req.URL.Scheme = targetURL.Scheme
-			req.URL.Host = targetURL.Host
-			req.Header.Set("X-Forwarded-Host", req.Header.Get("Host"))
-			req.Host = targetURL.Host
-			logger.Debug("正在代理请求，包含以下头部信息",
+		versions = append(versions, &versionUpstream{
+			upstream: &upstream{name: defaultVersionName, target: targetURL, proxy: newReverseProxy(serviceConfig.Name, targetURL, transport, logger)},
+			weight:   1,
+		})
+	}
+	for _, v := range serviceConfig.Versions {
+		targetURL, err := buildTargetURL(v.Scheme, v.Host, v.Port, v.ServiceName, v.Namespace)
+		if err != nil {
+			logger.Fatal("解析目标服务版本URL失败",
 				zap.String("serviceName", serviceConfig.Name),
-				zap.Any("headers", req.Header),
-				zap.String("path", req.URL.Path))
+				zap.String("version", v.Name),
+				zap.Error(err))
 		}
+		versions = append(versions, &versionUpstream{
+			upstream: &upstream{name: v.Name, target: targetURL, proxy: newReverseProxy(serviceConfig.Name, targetURL, transport, logger)},
+			weight:   v.Weight,
+			match:    v.Match,
+		})
+	}
+	for _, v := range versions {
+		logger.Info("构建目标服务URL成功",
+			zap.String("serviceName", serviceConfig.Name),
+			zap.String("version", v.name),
+			zap.Int("weight", v.weight),
+			zap.String("targetURL", v.target.String()))
+	}
 
-		proxy.ErrorHandler = func(rw http.ResponseWriter, req *http.Request, err error) {
-			logger.Error("反向代理错误",
-				zap.Error(err),
-				zap.String("targetService", serviceConfig.Name),
-				zap.String("targetURL", targetURL.String()),
-				zap.String("requestPath", req.URL.Path),
-			)
-			rw.Header().Set("Content-Type", "application/json")
-			rw.WriteHeader(http.StatusBadGateway)
-			fmt.Fprintf(rw, `{"code": 50201, "message": "Bad Gateway", "detail": "下游服务不可用或响应错误"}`)
-		}
+	splitter := newTrafficSplitter(serviceConfig.Name, serviceConfig.Sticky, versions)
+	state.splitters.register(splitter)
 
-		proxyPath := serviceConfig.Prefix
-		if !strings.HasSuffix(proxyPath, "/") {
-			proxyPath += "/"
-		}
-		proxyPath += "*action"
+	rewriter, err := newPathRewriter(serviceConfig)
+	if err != nil {
+		logger.Fatal("构建路径改写规则失败",
+			zap.String("serviceName", serviceConfig.Name),
+			zap.Error(err))
+	}
 
-		logger.Info("为服务注册代理处理器",
+	cacheRules, err := compileCacheRules(serviceConfig.Cache)
+	if err != nil {
+		logger.Fatal("编译缓存规则失败",
 			zap.String("serviceName", serviceConfig.Name),
-			zap.String("ginRoutePath", proxyPath),
-			zap.String("targetURL", targetURL.String()))
+			zap.Error(err))
+	}
 
-		// --- 修改: 不再传递 PublicPaths, 而是整个 svcCfg ---
-		handler := createProxyHandler(serviceConfig, cfg, logger, jwtUtil, proxy)
+	mirrors, err := newServiceMirrors(serviceConfig, otelTransport, logger)
+	if err != nil {
+		logger.Fatal("构建流量镜像失败",
+			zap.String("serviceName", serviceConfig.Name),
+			zap.Error(err))
+	}
+	if mirrors != nil {
+		state.mirrors.register(mirrors)
+	}
 
-		r.Any(proxyPath, handler)
-		if serviceConfig.Prefix != "" {
-			r.Any(serviceConfig.Prefix, handler)
-			logger.Info("同时为服务根前缀注册处理器",
-				zap.String("serviceName", serviceConfig.Name),
-				zap.String("exactPrefixPath", serviceConfig.Prefix))
-		}
+	rt := &serviceRuntime{
+		name:       serviceConfig.Name,
+		splitter:   splitter,
+		rewriter:   rewriter,
+		mirrors:    mirrors,
+		cache:      state.cache,
+		compressor: compress.New(cfg.Compression.Merge(serviceConfig.Compression)),
+		limits:     serviceConfig.Limits,
+		maxBody:    cfg.Limits.MaxBodyBytes,
+		transport:  transport,
+		timeout:    cfg.Server.RequestTimeout,
+		logger:     logger,
+		rules:      cacheRules,
 	}
+
+	// --- 修改: 不再传递 PublicPaths, 而是整个 svcCfg ---
+	return createProxyHandler(serviceConfig, cfg, logger, state, rt)
 }
 
 // createProxyHandler 创建一个 Gin 处理函数 (重构版 - 核心修改)
+// - 访问策略由 policy.Engine 统一判断：deny 规则 > publicPaths > routes > defaultPolicy
 func createProxyHandler(
 	svcCfg config.ServiceConfig, // <-- 使用整个服务配置
 	gatewayCfg *config.GatewayConfig,
 	logger *sharedCore.ZapLogger,
-	jwtUtil gatewayCore.JWTUtilityInterface,
-	proxy *httputil.ReverseProxy,
+	state *runtimeState, // 认证链、角色表与审计日志
+	rt *serviceRuntime,
 ) gin.HandlerFunc {
-	authHandler := mymiddleware.AuthMiddleware(jwtUtil)
 	permHandler := mymiddleware.PermissionMiddleware(gatewayCfg)
+	engine := policy.New(&svcCfg, state.roles)
+	audits := &auditor{log: state.audit, roles: state.roles, service: svcCfg.Name}
+	// exposeRoute 在开启 exposeRouteOnError 时为拒绝响应设置命中路由的名称
+	exposeRoute := func(c *gin.Context, route *config.RouteConfig) {
+		if gatewayCfg.ExposeRouteOnError && route != nil && route.Name != "" {
+			c.Header(RouteHeader, route.Name)
+		}
+	}
 
 	return func(c *gin.Context) {
+		c.Set(mymiddleware.ServiceConfigKey, &svcCfg)
+		// 公开路径不经过认证，也不会写入身份；客户端伪造的身份请求头必须在任何分支之前清除
+		auth.StripIdentityHeaders(c.Request.Header)
 		requestPath := c.Request.URL.Path
-		method := c.Request.Method
-		subPathForLookup := strings.TrimPrefix(requestPath, svcCfg.Prefix)
-		if !strings.HasPrefix(subPathForLookup, "/") && subPathForLookup != "" {
-			subPathForLookup = "/" + subPathForLookup
-		} else if subPathForLookup == "" {
-			subPathForLookup = "/"
-		}
+		subPathForLookup := policy.RelativePath(svcCfg.Prefix, requestPath)
 
 		traceIDVal, _ := c.Get("traceID")
 		logger.Debug("检查路径授权状态 (新)",
@@ -216,39 +240,53 @@ func createProxyHandler(
 			zap.Any("traceID", traceIDVal),
 		)
 
-		// --- 1. 优先检查公开路由 ---
-		isPublic := false
-		for _, publicPattern := range svcCfg.PublicPaths {
-			if matchPublicPath(publicPattern, subPathForLookup) {
-				isPublic = true
-				break
-			}
-		}
+		res := engine.Resolve(subPathForLookup, c.Request.Method)
+		c.Set(mymiddleware.PolicyResolutionKey, res)
 
-		if isPublic {
-			// 是公开路由 -> 直接代理
+		switch {
+		case !res.NeedsAuth():
+			// --- 1. 拒绝规则 / 公开路径 / 默认策略 public 或 deny -> 无需认证即可判断 ---
+			decision := engine.Authorize(res, nil)
+			audits.decision(c, decision)
+			if !decision.Allowed {
+				logger.Warn("请求被访问策略拒绝",
+					zap.String("serviceName", svcCfg.Name),
+					zap.String("path", requestPath),
+					zap.String("subPath", subPathForLookup),
+					zap.String("rule", decision.Rule),
+					zap.String("reason", decision.Reason),
+					routeField(res.Route),
+				)
+				exposeRoute(c, res.Route)
+				response.RespondError(c, decision.Status, decision.Code, decision.Message)
+				c.Abort()
+				return
+			}
+			state.shadow.report(c, logger, svcCfg.Name, decision)
 			logger.Debug("公开路径，直接代理",
 				zap.String("serviceName", svcCfg.Name),
-				zap.String("path", requestPath))
-			proxy.ServeHTTP(c.Writer, c.Request)
-			return // 结束处理
-		}
-
-		// --- 2. 如果不是公开路由，再检查是否匹配私有路由 ---
-		_, foundPrivate := mymiddleware.FindBestMatchingRoute(svcCfg.Routes, subPathForLookup, method)
+				zap.String("path", requestPath),
+				zap.String("rule", res.Rule),
+				routeField(res.Route))
+			rt.forward(c, subPathForLookup, res)
 
-		if foundPrivate {
-			// 是私有路由 -> 走认证流程
+		default:
+			// --- 3. 私有路由 / 默认策略 authenticated / 带豁免角色的拒绝规则 -> 走认证流程 ---
 			logger.Debug("私有路径，应用认证和权限中间件",
 				zap.String("serviceName", svcCfg.Name),
-				zap.String("path", requestPath))
+				zap.String("path", requestPath),
+				zap.String("rule", res.Rule),
+				routeField(res.Route))
 
-			authHandler(c)
-			if c.IsAborted() {
+			// 认证与权限中间件直接写出拒绝响应，先设置路由响应头，放行后再移除
+			exposeRoute(c, res.Route)
+			if !state.authChain.Authenticate(c, engine.AuthSchemes(res)) {
 				logger.Warn("请求被认证中间件中止",
 					zap.String("serviceName", svcCfg.Name),
 					zap.String("path", requestPath),
-					zap.Int("statusCode", c.Writer.Status()))
+					zap.Int("statusCode", c.Writer.Status()),
+					routeField(res.Route))
+				audits.authFailure(c, res.Rule)
 				return
 			}
 			logger.Debug("认证中间件通过",
@@ -256,31 +294,24 @@ func createProxyHandler(
 				zap.String("path", requestPath))
 
 			permHandler(c)
+			audits.permission(c, res.Rule)
 			if c.IsAborted() {
 				logger.Warn("请求被权限中间件中止",
 					zap.String("serviceName", svcCfg.Name),
 					zap.String("path", requestPath),
-					zap.Int("statusCode", c.Writer.Status()))
+					zap.String("rule", res.Rule),
+					zap.Int("statusCode", c.Writer.Status()),
+					routeField(res.Route))
 				return
 			}
-			logger.Debug("权限中间件通过",
-				zap.String("serviceName", svcCfg.Name),
-				zap.String("path", requestPath))
-
+			c.Writer.Header().Del(RouteHeader)
+			if v, ok := c.Get(mymiddleware.PolicyDecisionKey); ok {
+				state.shadow.report(c, logger, svcCfg.Name, v.(policy.Decision))
+			}
 			logger.Debug("所有中间件通过，执行代理",
 				zap.String("serviceName", svcCfg.Name),
 				zap.String("path", requestPath))
-			proxy.ServeHTTP(c.Writer, c.Request)
-
-		} else {
-			// --- 3. 既不匹配公开也不匹配私有 -> 拒绝访问 ---
-			logger.Warn("路径未匹配任何私有或公开路由，拒绝访问",
-				zap.String("serviceName", svcCfg.Name),
-				zap.String("path", requestPath),
-				zap.String("subPath", subPathForLookup),
-			)
-			response.RespondError(c, http.StatusNotFound, response.ErrCodeClientResourceNotFound, "路径未定义或无权访问")
-			c.Abort()
+			rt.forward(c, subPathForLookup, res)
 		}
 	}
 }
@@ -0,0 +1,151 @@
+package router
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/Xushengqwer/gateway/internal/config"
+)
+
+// compiledRewrite 是预编译后的服务级正则重写规则
+type compiledRewrite struct {
+	re      *regexp.Regexp
+	replace string
+}
+
+// pathRewriter 负责把网关对外的公开路径转换为上游服务实际挂载的路径
+// - 路由匹配与权限判断始终基于公开路径，改写只发生在转发前的最后一步
+type pathRewriter struct {
+	prefix   string            // 网关对外前缀
+	basePath string            // 替换前缀后的上游基础路径
+	rewrites []compiledRewrite // 正则重写规则
+}
+
+// newPathRewriter 根据服务配置创建路径改写器，正则非法时返回错误
+func newPathRewriter(svc config.ServiceConfig) (*pathRewriter, error) {
+	pr := &pathRewriter{
+		prefix:   svc.Prefix,
+		basePath: svc.UpstreamBasePath(),
+	}
+	for i, rule := range svc.Rewrites {
+		re, err := regexp.Compile(rule.Match)
+		if err != nil {
+			return nil, fmt.Errorf("服务 %s 的第 %d 条重写规则正则无效: %w", svc.Name, i+1, err)
+		}
+		pr.rewrites = append(pr.rewrites, compiledRewrite{re: re, replace: rule.Replace})
+	}
+	return pr, nil
+}
+
+// apply 将请求改写为发往上游的路径
+// - subPath: 相对服务前缀的公开子路径（以 "/" 开头）
+// - route: 命中的私有路由（公开路径为 nil），其 Rewrite 模板优先于原子路径
+// - params: 路由匹配时提取的路径参数，用于渲染 Rewrite 模板
+func (pr *pathRewriter) apply(req *http.Request, subPath string, route *config.RouteConfig, params map[string]string) {
+	targetSubPath := subPath
+	var extraQuery string
+	if route != nil && route.Rewrite != "" {
+		targetSubPath, extraQuery = splitPathQuery(renderRouteTemplate(route.Rewrite, params))
+	}
+
+	upstreamPath := pr.basePath + targetSubPath
+	if upstreamPath == "" {
+		upstreamPath = "/"
+	}
+
+	for _, rule := range pr.rewrites {
+		if rule.re.MatchString(upstreamPath) {
+			var ruleQuery string
+			upstreamPath, ruleQuery = splitPathQuery(rule.re.ReplaceAllString(upstreamPath, rule.replace))
+			extraQuery = joinQuery(extraQuery, ruleQuery)
+			break
+		}
+	}
+
+	if upstreamPath == req.URL.Path && extraQuery == "" {
+		return
+	}
+
+	if pr.basePath != pr.prefix {
+		req.Header.Set("X-Forwarded-Prefix", pr.prefix)
+	}
+	req.URL.Path = upstreamPath
+	req.URL.RawPath = ""
+	req.URL.RawQuery = joinQuery(req.URL.RawQuery, extraQuery)
+}
+
+// renderRouteTemplate 用路由参数填充改写模板中的 ":name"、"{name}" 与 "*name" 占位符
+// - 路径段和查询参数值均可引用参数，未知参数保持原样
+// - 具名通配 "*path" 的值可含多段，填入路径时保留其中的 "/"
+func renderRouteTemplate(template string, params map[string]string) string {
+	path, query := splitPathQuery(template)
+
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		segments[i] = substituteParam(segment, params, escapePathValue)
+	}
+	path = strings.Join(segments, "/")
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+
+	if query == "" {
+		return path
+	}
+	pairs := strings.Split(query, "&")
+	for i, pair := range pairs {
+		if key, value, ok := strings.Cut(pair, "="); ok {
+			pairs[i] = key + "=" + substituteParam(value, params, url.QueryEscape)
+		}
+	}
+	return path + "?" + strings.Join(pairs, "&")
+}
+
+// substituteParam 若 token 为参数占位符则替换为转义后的参数值
+func substituteParam(token string, params map[string]string, escape func(string) string) string {
+	var name string
+	switch {
+	case strings.HasPrefix(token, ":"):
+		name = token[1:]
+	case strings.HasPrefix(token, "*"):
+		name = token[1:]
+	case strings.HasPrefix(token, "{") && strings.HasSuffix(token, "}"):
+		name = token[1 : len(token)-1]
+	default:
+		return token
+	}
+	if value, ok := params[name]; ok {
+		return escape(value)
+	}
+	return token
+}
+
+// escapePathValue 逐段转义参数值，保留段之间的 "/"
+func escapePathValue(value string) string {
+	parts := strings.Split(value, "/")
+	for i, part := range parts {
+		parts[i] = url.PathEscape(part)
+	}
+	return strings.Join(parts, "/")
+}
+
+// splitPathQuery 把 "path?query" 拆分为路径与原始查询串
+func splitPathQuery(s string) (string, string) {
+	path, query, _ := strings.Cut(s, "?")
+	return path, query
+}
+
+// joinQuery 拼接两段原始查询串，忽略空串
+func joinQuery(a, b string) string {
+	switch {
+	case a == "":
+		return b
+	case b == "":
+		return a
+	default:
+		return a + "&" + b
+	}
+}
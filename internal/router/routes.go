@@ -0,0 +1,87 @@
+package router
+
+import (
+	"github.com/Xushengqwer/gateway/internal/config"
+	"github.com/Xushengqwer/gateway/internal/policy"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// RouteHeader 是 exposeRouteOnError 开启时，网关拒绝请求的响应中携带命中路由名称的响应头
+const RouteHeader = "X-Gateway-Route"
+
+// RouteInfo 是管理 API GET /routes 输出的一条路由
+type RouteInfo struct {
+	Service     string            `json:"service"`
+	Prefix      string            `json:"prefix"`
+	Name        string            `json:"name,omitempty"`
+	Description string            `json:"description,omitempty"`
+	Tags        []string          `json:"tags,omitempty"`
+	Metadata    map[string]string `json:"metadata,omitempty"`
+	Methods     []string          `json:"methods,omitempty"`
+	Path        string            `json:"path"`
+	Rule        string            `json:"rule"`            // 与日志、审计中的 rule 字段一致
+	Roles       []string          `json:"roles,omitempty"` // roles / minRole 要求的具名角色
+	Auth        []string          `json:"auth,omitempty"`  // 生效的认证方式（路由级优先，其次服务级）
+	Enforce     bool              `json:"enforce"`
+}
+
+// listRoutes 按配置顺序列出全部服务的路由及其元数据
+func listRoutes(cfg *config.GatewayConfig) []RouteInfo {
+	result := make([]RouteInfo, 0)
+	for i := range cfg.Services {
+		svc := &cfg.Services[i]
+		for j := range svc.Routes {
+			route := &svc.Routes[j]
+			auth := route.Auth
+			if len(auth) == 0 {
+				auth = svc.Auth
+			}
+			result = append(result, RouteInfo{
+				Service:     svc.Name,
+				Prefix:      svc.Prefix,
+				Name:        route.Name,
+				Description: route.Description,
+				Tags:        route.Tags,
+				Metadata:    route.Metadata,
+				Methods:     route.Methods,
+				Path:        route.Path,
+				Rule:        policy.RouteRule(route),
+				Roles:       route.RequiredRoleNames(),
+				Auth:        auth,
+				Enforce:     svc.Enforced(route.Enforce),
+			})
+		}
+	}
+	return result
+}
+
+// routeField 把命中路由的名称与标签作为一个日志字段，未命中或未命名时跳过
+func routeField(route *config.RouteConfig) zap.Field {
+	if route == nil || (route.Name == "" && len(route.Tags) == 0) {
+		return zap.Skip()
+	}
+	return zap.Object("route", routeLog{route})
+}
+
+type routeLog struct{ route *config.RouteConfig }
+
+func (r routeLog) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	if r.route.Name != "" {
+		enc.AddString("name", r.route.Name)
+	}
+	if len(r.route.Tags) > 0 {
+		return enc.AddArray("tags", tagList(r.route.Tags))
+	}
+	return nil
+}
+
+type tagList []string
+
+func (t tagList) MarshalLogArray(enc zapcore.ArrayEncoder) error {
+	for _, tag := range t {
+		enc.AppendString(tag)
+	}
+	return nil
+}
@@ -0,0 +1,234 @@
+package router
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/Xushengqwer/gateway/internal/apikey"
+	"github.com/Xushengqwer/gateway/internal/audit"
+	"github.com/Xushengqwer/gateway/internal/auth"
+	"github.com/Xushengqwer/gateway/internal/cache"
+	"github.com/Xushengqwer/gateway/internal/compress"
+	"github.com/Xushengqwer/gateway/internal/config"
+	gatewayCore "github.com/Xushengqwer/gateway/internal/core"
+	mymiddleware "github.com/Xushengqwer/gateway/internal/middleware"
+	"github.com/Xushengqwer/gateway/internal/policy"
+	sharedCore "github.com/Xushengqwer/go-common/core"
+	"github.com/Xushengqwer/go-common/response"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// runtimeState 汇总运行时可由管理 API 查看或调整的组件
+type runtimeState struct {
+	splitters *splitterRegistry
+	mirrors   *mirrorRegistry
+	cache     *cache.Cache  // 未启用响应缓存时为 nil
+	apiKeys   *apikey.Store // 未配置 apiKeys 时为 nil
+
+	// authChain 按路由配置的认证方式认证请求，所有服务共享（API Key 的套餐限流按 Key 计算）
+	authChain *auth.Chain
+	// roles 是展开继承关系后的角色表，供各服务的策略引擎使用
+	roles *config.RoleHierarchy
+	// audit 记录认证与权限判断，未启用审计时为 nil
+	audit *audit.Logger
+	// shadow 统计试运行规则本应拒绝的请求
+	shadow *shadowRegistry
+	// docs 定期刷新聚合文档，未启用文档时为 nil
+	docs *docsServer
+}
+
+// newRuntimeState 创建运行时状态，按配置决定是否创建响应缓存与 API Key 存储
+func newRuntimeState(cfg *config.GatewayConfig, logger *sharedCore.ZapLogger, jwtUtil gatewayCore.JWTUtilityInterface) *runtimeState {
+	state := &runtimeState{
+		splitters: newSplitterRegistry(),
+		mirrors:   &mirrorRegistry{},
+		shadow:    newShadowRegistry(),
+	}
+	if cfg.ResponseCache != nil {
+		state.cache = cache.New(cfg.ResponseCache, logger)
+	}
+	if cfg.APIKeys != nil {
+		store, err := apikey.Open(cfg.APIKeys.StoreFile)
+		if err != nil {
+			logger.Fatal("打开 API Key 存储失败", zap.String("storeFile", cfg.APIKeys.StoreFile), zap.Error(err))
+		}
+		state.apiKeys = store
+	}
+	state.authChain = mymiddleware.NewAuthChain(cfg, jwtUtil, state.apiKeys)
+
+	roles, err := cfg.RoleHierarchy()
+	if err != nil {
+		logger.Fatal("角色定义无效", zap.Error(err))
+	}
+	state.roles = roles
+
+	auditLog, err := audit.New(cfg.Audit)
+	if err != nil {
+		logger.Fatal("初始化审计日志失败", zap.Error(err))
+	}
+	state.audit = auditLog
+	return state
+}
+
+// Close 释放运行时状态持有的资源：停止聚合文档的后台刷新，刷新并关闭审计日志文件
+func (s *runtimeState) Close() error {
+	if s.docs != nil {
+		s.docs.stop()
+	}
+	return s.audit.Close()
+}
+
+// serviceRuntime 汇总单个服务在转发阶段用到的组件
+type serviceRuntime struct {
+	name       string
+	splitter   *trafficSplitter
+	rewriter   *pathRewriter
+	mirrors    *serviceMirrors      // 未配置镜像时为 nil
+	cache      *cache.Cache         // 未启用响应缓存时为 nil
+	compressor *compress.Compressor // 未启用压缩与解压时为 nil
+	limits     *config.BodyLimit    // 服务级请求体限制，可为 nil
+	maxBody    int64                // 全局请求体上限，0 表示不限制
+	transport  *serviceTransport
+	timeout    time.Duration // 全局 server.requestTimeout，服务与路由未配置整体超时时使用
+	logger     *sharedCore.ZapLogger
+	rules      []cacheRule
+}
+
+// cacheRule 是预编译路径模式后的缓存规则
+type cacheRule struct {
+	pattern *policy.Pattern
+	rule    config.CacheRule
+}
+
+// compileCacheRules 编译服务的缓存规则路径，按配置顺序匹配
+func compileCacheRules(rules []config.CacheRule) ([]cacheRule, error) {
+	compiled := make([]cacheRule, 0, len(rules))
+	for _, rule := range rules {
+		pattern, err := policy.CompilePattern(rule.Path)
+		if err != nil {
+			return nil, err
+		}
+		compiled = append(compiled, cacheRule{pattern: pattern, rule: rule})
+	}
+	return compiled, nil
+}
+
+// forward 完成认证授权之后的转发：改写路径、采样镜像、选择上游版本并执行反向代理
+// - subPath: 相对服务前缀的公开子路径
+// - res: 访问策略的匹配结果，res.Route 为命中的私有路由（公开路径为 nil），res.Params 为其路径参数
+func (rt *serviceRuntime) forward(c *gin.Context, subPath string, res policy.Resolution) {
+	route := res.Route
+	c.Request = withRouteTimeouts(c.Request, route)
+	if c.GetBool(mymiddleware.SkipTimeoutKey) {
+		if d := rt.transport.overallTimeout(route, rt.timeout); d > 0 {
+			ctx, cancel := context.WithTimeout(c.Request.Context(), d)
+			defer cancel()
+			c.Request = c.Request.WithContext(ctx)
+		}
+	}
+
+	var routeLimits *config.BodyLimit
+	if route != nil {
+		routeLimits = route.Limits
+	}
+	limit := config.MergeBodyLimits(rt.maxBody, rt.limits, routeLimits)
+	if !rt.enforceBodyLimit(c, limit) {
+		return
+	}
+	if rt.compressor != nil && !rt.prepareBody(c) {
+		return
+	}
+	if !rt.enforcePartTypes(c, limit) {
+		return
+	}
+
+	if rt.compressor != nil {
+		if cw := rt.compressor.Wrap(c.Writer, c.Request); cw != nil {
+			c.Writer = cw
+			defer func() {
+				cw.Close()
+				c.Writer = cw.ResponseWriter
+			}()
+		}
+	}
+
+	publicPath := c.Request.URL.Path
+	rt.rewriter.apply(c.Request, subPath, route, res.Params)
+
+	// 经过认证的请求（如默认策略 authenticated）可能携带用户相关的响应，不走响应缓存
+	_, authenticated := auth.PrincipalFrom(c)
+	if route == nil && !authenticated && rt.cache != nil && cache.Cacheable(c.Request) {
+		if rule, ok := rt.matchCacheRule(subPath); ok {
+			rt.serveCached(c, publicPath, rule)
+			return
+		}
+	}
+
+	var snapshot *mirrorSnapshot
+	m := rt.mirrors.forRoute(route)
+	if m != nil {
+		snapshot = m.capture(c.Request)
+	}
+
+	target := rt.splitter.pickForContext(c)
+	rt.logger.Debug("选择上游版本",
+		zap.String("serviceName", rt.name),
+		zap.String("version", target.name),
+		zap.String("path", c.Request.URL.Path))
+
+	start := time.Now()
+	target.proxy.ServeHTTP(c.Writer, c.Request)
+
+	if snapshot != nil {
+		m.dispatch(snapshot, c.Writer.Status(), time.Since(start))
+	}
+}
+
+// prepareBody 解压客户端上传的压缩请求体，请求体编码无效时直接响应错误并返回 false
+func (rt *serviceRuntime) prepareBody(c *gin.Context) bool {
+	err := rt.compressor.DecompressRequest(c.Writer, c.Request)
+	switch {
+	case err == nil:
+		return true
+	case errors.Is(err, compress.ErrUnsupportedEncoding):
+		response.RespondError(c, http.StatusUnsupportedMediaType, mymiddleware.ErrCodeClientUnsupportedMedia, "不支持的请求体编码")
+	default:
+		response.RespondError(c, http.StatusBadRequest, response.ErrCodeClientInvalidInput, "请求体解压失败")
+	}
+	c.Abort()
+	rt.logger.Warn("拒绝无法解压的请求体",
+		zap.String("serviceName", rt.name),
+		zap.String("contentEncoding", c.Request.Header.Get("Content-Encoding")),
+		zap.Error(err))
+	return false
+}
+
+// matchCacheRule 查找适用于公开子路径的缓存规则
+func (rt *serviceRuntime) matchCacheRule(subPath string) (config.CacheRule, bool) {
+	for _, r := range rt.rules {
+		if r.pattern.Match(subPath) {
+			return r.rule, true
+		}
+	}
+	return config.CacheRule{}, false
+}
+
+// serveCached 通过响应缓存处理公开 GET 请求
+// - 回源时不使用用户信息选择版本（公开请求无用户身份），也不写入版本 Cookie，避免响应因 Set-Cookie 不可缓存
+func (rt *serviceRuntime) serveCached(c *gin.Context, publicPath string, rule config.CacheRule) {
+	fetch := func(w http.ResponseWriter, req *http.Request) {
+		// 回源请求已与客户端连接解绑，需要单独设置整体超时
+		if d := rt.transport.overallTimeout(nil, rt.timeout); d > 0 {
+			ctx, cancel := context.WithTimeout(req.Context(), d)
+			defer cancel()
+			req = req.WithContext(ctx)
+		}
+		target, _ := rt.splitter.pick(req, "", nil, false)
+		target.proxy.ServeHTTP(w, req)
+	}
+	rt.cache.Serve(c.Writer, c.Request, publicPath, cache.PolicyFromRule(rt.name, rule), fetch)
+}
@@ -0,0 +1,103 @@
+package router
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/Xushengqwer/gateway/internal/policy"
+	sharedCore "github.com/Xushengqwer/go-common/core"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// ShadowStats 是一条试运行规则的统计：本应拒绝但被放行的请求数
+type ShadowStats struct {
+	Service  string    `json:"service"`
+	Rule     string    `json:"rule"`
+	Route    string    `json:"route,omitempty"` // 命中路由的名称
+	Status   int       `json:"status"`          // 强制执行时本应返回的状态码
+	Count    int64     `json:"count"`
+	LastSeen time.Time `json:"lastSeen"`
+	LastPath string    `json:"lastPath"`
+}
+
+type shadowKey struct {
+	service string
+	rule    string
+	route   string
+	status  int
+}
+
+// shadowRegistry 统计试运行（enforce: false）规则本应拒绝的请求，供管理 API 查看
+type shadowRegistry struct {
+	mu     sync.Mutex
+	counts map[shadowKey]*ShadowStats
+}
+
+func newShadowRegistry() *shadowRegistry {
+	return &shadowRegistry{counts: make(map[shadowKey]*ShadowStats)}
+}
+
+// report 在判断结果为试运行拒绝时记录日志并计数，其他结果忽略
+func (sr *shadowRegistry) report(c *gin.Context, logger *sharedCore.ZapLogger, service string, d policy.Decision) {
+	if !d.Shadow {
+		return
+	}
+	route := resolvedRoute(c)
+	logger.Warn("试运行规则本应拒绝该请求，已放行",
+		zap.String("serviceName", service),
+		zap.String("method", c.Request.Method),
+		zap.String("path", c.Request.URL.Path),
+		zap.String("rule", d.Rule),
+		zap.Int("wouldStatus", d.Status),
+		zap.String("reason", d.Reason),
+		routeField(route),
+	)
+
+	var routeName string
+	if route != nil {
+		routeName = route.Name
+	}
+	key := shadowKey{service: service, rule: d.Rule, route: routeName, status: d.Status}
+	sr.mu.Lock()
+	defer sr.mu.Unlock()
+	st, ok := sr.counts[key]
+	if !ok {
+		st = &ShadowStats{Service: service, Rule: d.Rule, Route: routeName, Status: d.Status}
+		sr.counts[key] = st
+	}
+	st.Count++
+	st.LastSeen = time.Now()
+	st.LastPath = c.Request.URL.Path
+}
+
+// stats 返回全部试运行规则的统计（按服务名、规则排序）
+func (sr *shadowRegistry) stats() []ShadowStats {
+	sr.mu.Lock()
+	defer sr.mu.Unlock()
+	result := make([]ShadowStats, 0, len(sr.counts))
+	for _, st := range sr.counts {
+		result = append(result, *st)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Service != result[j].Service {
+			return result[i].Service < result[j].Service
+		}
+		if result[i].Rule != result[j].Rule {
+			return result[i].Rule < result[j].Rule
+		}
+		return result[i].Status < result[j].Status
+	})
+	return result
+}
+
+// reset 清空统计并返回清除的条目数，通常在调整规则后重新开始观察
+func (sr *shadowRegistry) reset() int {
+	sr.mu.Lock()
+	defer sr.mu.Unlock()
+	n := len(sr.counts)
+	sr.counts = make(map[shadowKey]*ShadowStats)
+	return n
+}
@@ -0,0 +1,182 @@
+package router
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Xushengqwer/gateway/internal/config"
+	mymiddleware "github.com/Xushengqwer/gateway/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+)
+
+// 与 http.DefaultTransport 一致的默认值
+const (
+	defaultConnectTimeout      = 30 * time.Second
+	defaultKeepAlive           = 30 * time.Second
+	defaultTLSHandshakeTimeout = 10 * time.Second
+	defaultIdleConnTimeout     = 90 * time.Second
+	defaultMaxIdleConns        = 100
+)
+
+// routeTimeoutsKey 是请求 Context 中保存路由级超时覆盖值的键
+type routeTimeoutsKey struct{}
+
+// serviceTransport 是服务专用的 http.RoundTripper
+// - 连接池按服务隔离，一个慢服务耗尽连接不会影响其他服务
+// - 路由覆盖了连接级超时（connect / tlsHandshake / responseHeader）时，按超时组合懒加载额外的 Transport
+type serviceTransport struct {
+	cfg      config.TransportConfig
+	timeouts config.TimeoutConfig // 服务级超时
+	tls      *tls.Config          // https 上游的 TLS 设置，nil 表示使用默认值
+	tracing  bool
+
+	mu         sync.Mutex
+	transports map[config.TimeoutConfig]http.RoundTripper
+}
+
+// newServiceTransport 根据服务配置创建专用 Transport，启用追踪时用 otelhttp 包装
+// - 上游 TLS 配置（CA、客户端证书）无法加载时返回错误
+func newServiceTransport(svc config.ServiceConfig, tracing bool) (*serviceTransport, error) {
+	tlsConfig, err := newUpstreamTLSConfig(svc.TLS)
+	if err != nil {
+		return nil, err
+	}
+	st := &serviceTransport{
+		tls:        tlsConfig,
+		timeouts:   config.TimeoutConfig{}.Merge(svc.Timeouts),
+		tracing:    tracing,
+		transports: make(map[config.TimeoutConfig]http.RoundTripper),
+	}
+	if svc.Transport != nil {
+		st.cfg = *svc.Transport
+	}
+	return st, nil
+}
+
+// RoundTrip 实现 http.RoundTripper，按请求携带的路由级超时选择底层 Transport
+func (st *serviceTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	timeouts := st.timeouts
+	if override, ok := req.Context().Value(routeTimeoutsKey{}).(*config.TimeoutConfig); ok {
+		timeouts = timeouts.Merge(override)
+	}
+	timeouts.Overall = 0 // 整体超时由 Context 控制，不影响 Transport 的选择
+	return st.get(timeouts).RoundTrip(req)
+}
+
+// get 返回给定超时组合对应的 Transport，不存在时创建
+func (st *serviceTransport) get(timeouts config.TimeoutConfig) http.RoundTripper {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	if rt, ok := st.transports[timeouts]; ok {
+		return rt
+	}
+	rt := st.build(timeouts)
+	st.transports[timeouts] = rt
+	return rt
+}
+
+// build 创建底层 http.Transport，未配置的字段取与 http.DefaultTransport 相同的默认值
+func (st *serviceTransport) build(timeouts config.TimeoutConfig) http.RoundTripper {
+	dialer := &net.Dialer{
+		Timeout:   orDefault(timeouts.Connect, defaultConnectTimeout),
+		KeepAlive: orDefault(st.cfg.KeepAlive, defaultKeepAlive),
+	}
+	maxIdle := st.cfg.MaxIdleConns
+	if maxIdle == 0 {
+		maxIdle = defaultMaxIdleConns
+	}
+	http2 := st.cfg.HTTP2 == nil || *st.cfg.HTTP2
+
+	transport := &http.Transport{
+		Proxy:                 http.ProxyFromEnvironment,
+		DialContext:           dialer.DialContext,
+		ForceAttemptHTTP2:     http2,
+		MaxIdleConns:          maxIdle,
+		MaxIdleConnsPerHost:   st.cfg.MaxIdleConnsPerHost,
+		MaxConnsPerHost:       st.cfg.MaxConnsPerHost,
+		IdleConnTimeout:       orDefault(st.cfg.IdleConnTimeout, defaultIdleConnTimeout),
+		TLSHandshakeTimeout:   orDefault(timeouts.TLSHandshake, defaultTLSHandshakeTimeout),
+		ResponseHeaderTimeout: timeouts.ResponseHeader,
+		ExpectContinueTimeout: 1 * time.Second,
+		DisableKeepAlives:     st.cfg.DisableKeepAlives,
+	}
+	if st.tls != nil {
+		transport.TLSClientConfig = st.tls.Clone()
+	}
+	if !http2 {
+		// 非 nil 的空 TLSNextProto 会禁用 HTTP/2
+		transport.TLSNextProto = make(map[string]func(string, *tls.Conn) http.RoundTripper)
+	}
+	if st.tracing {
+		return otelhttp.NewTransport(transport)
+	}
+	return transport
+}
+
+// overallTimeout 返回请求的整体超时：路由 > 服务 > 全局 server.requestTimeout
+func (st *serviceTransport) overallTimeout(route *config.RouteConfig, fallback time.Duration) time.Duration {
+	timeouts := st.timeouts
+	if route != nil {
+		timeouts = timeouts.Merge(route.Timeouts)
+	}
+	return orDefault(timeouts.Overall, fallback)
+}
+
+// withRouteTimeouts 把路由级超时写入请求 Context，供 serviceTransport 选择 Transport
+func withRouteTimeouts(req *http.Request, route *config.RouteConfig) *http.Request {
+	if route == nil || route.Timeouts == nil {
+		return req
+	}
+	return req.WithContext(context.WithValue(req.Context(), routeTimeoutsKey{}, route.Timeouts))
+}
+
+// skipGlobalTimeout 为配置了整体超时的服务跳过全局 RequestTimeoutMiddleware
+// - 必须注册在 RequestTimeoutMiddleware 之前；被跳过的请求由 serviceRuntime.forward 自行设置超时
+func skipGlobalTimeout(cfg *config.GatewayConfig) gin.HandlerFunc {
+	var prefixes []string
+	for _, group := range cfg.ServiceGroups() {
+		if groupHasOverallTimeout(group) {
+			prefixes = append(prefixes, group.Prefix)
+		}
+	}
+	return func(c *gin.Context) {
+		path := c.Request.URL.Path
+		for _, prefix := range prefixes {
+			if path == prefix || strings.HasPrefix(path, prefix+"/") {
+				c.Set(mymiddleware.SkipTimeoutKey, true)
+				break
+			}
+		}
+		c.Next()
+	}
+}
+
+// groupHasOverallTimeout 判断前缀组内是否有服务或路由配置了整体超时
+func groupHasOverallTimeout(group config.ServiceGroup) bool {
+	for _, svc := range group.Services {
+		if svc.Timeouts != nil && svc.Timeouts.Overall > 0 {
+			return true
+		}
+		for _, route := range svc.Routes {
+			if route.Timeouts != nil && route.Timeouts.Overall > 0 {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// orDefault 在 d 为零值时返回默认值
+func orDefault(d, def time.Duration) time.Duration {
+	if d > 0 {
+		return d
+	}
+	return def
+}
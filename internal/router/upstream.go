@@ -0,0 +1,105 @@
+package router
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+
+	mymiddleware "github.com/Xushengqwer/gateway/internal/middleware"
+	sharedCore "github.com/Xushengqwer/go-common/core"
+	"github.com/Xushengqwer/go-common/response"
+
+	"go.uber.org/zap"
+)
+
+// upstream 表示一个具体的上游目标及其反向代理
+type upstream struct {
+	name   string // 版本名称，未配置多版本时为 defaultVersionName
+	target *url.URL
+	proxy  *httputil.ReverseProxy
+}
+
+// buildTargetURL 根据部署方式构建上游地址
+// - 配置了 serviceName 时按 K8s Service DNS 拼接，端口默认 80
+// - 否则要求同时指定 host 与 port
+func buildTargetURL(scheme, host string, port int, serviceName, namespace string) (*url.URL, error) {
+	if scheme == "" {
+		scheme = "http"
+	}
+
+	targetHost := host
+	if serviceName != "" {
+		if namespace == "" {
+			namespace = "default"
+		}
+		targetHost = fmt.Sprintf("%s.%s.svc.cluster.local", serviceName, namespace)
+		if port == 0 {
+			port = 80
+		}
+	} else if host == "" || port == 0 {
+		return nil, errors.New("非K8s模式下服务配置无效：Host 或 Port 未指定")
+	}
+
+	return url.Parse(fmt.Sprintf("%s://%s:%d", scheme, targetHost, port))
+}
+
+// newReverseProxy 为指定上游创建反向代理，统一设置转发头和错误响应
+func newReverseProxy(serviceName string, targetURL *url.URL, transport http.RoundTripper, logger *sharedCore.ZapLogger) *httputil.ReverseProxy {
+	proxy := httputil.NewSingleHostReverseProxy(targetURL)
+	if transport != nil {
+		proxy.Transport = transport
+	}
+
+	proxy.Director = func(req *http.Request) {
+		req.URL.Scheme = targetURL.Scheme
+		req.URL.Host = targetURL.Host
+		req.Header.Set("X-Forwarded-Host", req.Header.Get("Host"))
+		req.Host = targetURL.Host
+		logger.Debug("正在代理请求，包含以下头部信息",
+			zap.String("serviceName", serviceName),
+			zap.Any("headers", req.Header),
+			zap.String("path", req.URL.Path))
+	}
+
+	proxy.ErrorHandler = func(rw http.ResponseWriter, req *http.Request, err error) {
+		var maxErr *http.MaxBytesError
+		if errors.As(err, &maxErr) {
+			// 客户端请求体在转发途中超过限制，不是上游故障
+			logger.Warn("请求体超过限制，中止转发",
+				zap.String("targetService", serviceName),
+				zap.String("requestPath", req.URL.Path),
+				zap.Int64("maxBodyBytes", maxErr.Limit))
+			rw.Header().Set("Content-Type", "application/json")
+			rw.WriteHeader(http.StatusRequestEntityTooLarge)
+			_ = json.NewEncoder(rw).Encode(response.APIResponse[any]{Code: mymiddleware.ErrCodeClientBodyTooLarge, Message: "请求体过大"})
+			return
+		}
+		var netErr net.Error
+		if errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout()) {
+			logger.Warn("上游服务响应超时",
+				zap.Error(err),
+				zap.String("targetService", serviceName),
+				zap.String("targetURL", targetURL.String()),
+				zap.String("requestPath", req.URL.Path))
+			rw.Header().Set("Content-Type", "application/json")
+			rw.WriteHeader(http.StatusGatewayTimeout)
+			_ = json.NewEncoder(rw).Encode(response.APIResponse[any]{Code: response.ErrCodeServerTimeout, Message: "上游服务响应超时"})
+			return
+		}
+		logger.Error("反向代理错误",
+			zap.Error(err),
+			zap.String("targetService", serviceName),
+			zap.String("targetURL", targetURL.String()),
+			zap.String("requestPath", req.URL.Path),
+		)
+		rw.Header().Set("Content-Type", "application/json")
+		rw.WriteHeader(http.StatusBadGateway)
+		fmt.Fprintf(rw, `{"code": 50201, "message": "Bad Gateway", "detail": "下游服务不可用或响应错误"}`)
+	}
+	return proxy
+}
@@ -0,0 +1,126 @@
+package router
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/Xushengqwer/gateway/internal/config"
+)
+
+// clientCertCheckInterval 是检查客户端证书文件是否更新的最小间隔
+const clientCertCheckInterval = 30 * time.Second
+
+// newUpstreamTLSConfig 根据服务的上游 TLS 配置构建 tls.Config，未配置时返回 nil（使用 Go 默认行为）
+func newUpstreamTLSConfig(cfg *config.UpstreamTLSConfig) (*tls.Config, error) {
+	if cfg == nil {
+		return nil, nil
+	}
+	minVersion, err := config.ParseTLSVersion(cfg.MinVersion)
+	if err != nil {
+		return nil, err
+	}
+	tlsConfig := &tls.Config{
+		MinVersion: minVersion,
+		ServerName: cfg.ServerName,
+	}
+
+	if cfg.CAFile != "" {
+		pem, err := os.ReadFile(cfg.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("读取上游 CA 文件失败: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("上游 CA 文件 %s 中没有有效的 PEM 证书", cfg.CAFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	if cfg.CertFile != "" {
+		loader, err := newClientCertLoader(cfg.CertFile, cfg.KeyFile)
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig.GetClientCertificate = loader.get
+	}
+
+	if len(cfg.PinnedSHA256) > 0 {
+		pins := make(map[string]bool, len(cfg.PinnedSHA256))
+		for _, p := range cfg.PinnedSHA256 {
+			pins[p] = true
+		}
+		tlsConfig.VerifyConnection = func(cs tls.ConnectionState) error {
+			for _, cert := range cs.PeerCertificates {
+				sum := sha256.Sum256(cert.RawSubjectPublicKeyInfo)
+				if pins[base64.StdEncoding.EncodeToString(sum[:])] {
+					return nil
+				}
+			}
+			return errors.New("上游证书公钥与 pinnedSHA256 均不匹配")
+		}
+	}
+	return tlsConfig, nil
+}
+
+// clientCertLoader 提供出示给上游的客户端证书，文件更新后自动加载新证书
+type clientCertLoader struct {
+	certFile, keyFile string
+
+	mu        sync.Mutex
+	cert      *tls.Certificate
+	modTime   time.Time
+	checkedAt time.Time
+}
+
+// newClientCertLoader 加载客户端证书，首次加载失败时返回错误
+func newClientCertLoader(certFile, keyFile string) (*clientCertLoader, error) {
+	l := &clientCertLoader{certFile: certFile, keyFile: keyFile}
+	if err := l.load(); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+// get 实现 tls.Config.GetClientCertificate，最多每 clientCertCheckInterval 检查一次文件是否更新
+// - 重新加载失败时继续使用旧证书
+func (l *clientCertLoader) get(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if time.Since(l.checkedAt) >= clientCertCheckInterval {
+		l.checkedAt = time.Now()
+		if info, err := os.Stat(l.certFile); err == nil && info.ModTime().After(l.modTime) {
+			_ = l.loadLocked()
+		}
+	}
+	return l.cert, nil
+}
+
+// load 加载证书与私钥
+func (l *clientCertLoader) load() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.loadLocked()
+}
+
+// loadLocked 加载证书与私钥，调用方需持有锁
+func (l *clientCertLoader) loadLocked() error {
+	info, err := os.Stat(l.certFile)
+	if err != nil {
+		return fmt.Errorf("读取上游客户端证书失败: %w", err)
+	}
+	cert, err := tls.LoadX509KeyPair(l.certFile, l.keyFile)
+	if err != nil {
+		return fmt.Errorf("加载上游客户端证书失败: %w", err)
+	}
+	l.cert = &cert
+	l.modTime = info.ModTime()
+	l.checkedAt = time.Now()
+	return nil
+}
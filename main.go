@@ -12,9 +12,11 @@ import (
 	"syscall"
 	"time"
 
+	"github.com/Xushengqwer/gateway/internal/cli"
 	"github.com/Xushengqwer/gateway/internal/config"
 	"github.com/Xushengqwer/gateway/internal/constant"
 	gatewayCore "github.com/Xushengqwer/gateway/internal/core"
+	"github.com/Xushengqwer/gateway/internal/listener"
 	"github.com/Xushengqwer/gateway/internal/router"
 	sharedCore "github.com/Xushengqwer/go-common/core"
 	sharedTracing "github.com/Xushengqwer/go-common/core/tracing"
@@ -24,6 +26,20 @@ import (
 )
 
 func main() {
+	// 子命令分发：不带子命令时启动网关服务
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "validate":
+			os.Exit(cli.RunValidate(os.Args[2:]))
+		case "apikey":
+			os.Exit(cli.RunAPIKey(os.Args[2:]))
+		case "test-policy":
+			os.Exit(cli.RunTestPolicy(os.Args[2:]))
+		case "import-openapi":
+			os.Exit(cli.RunImportOpenAPI(os.Args[2:]))
+		}
+	}
+
 	var configFile string
 	flag.StringVar(&configFile, "config", "./config/development.yaml", "Path to the configuration file.")
 	flag.Parse()
@@ -33,6 +49,9 @@ func main() {
 	if err := sharedCore.LoadConfig(configFile, &cfg); err != nil {
 		log.Fatalf("加载配置失败: %v", err)
 	}
+	if err := cfg.Validate(); err != nil {
+		log.Fatalf("配置校验失败: %v", err)
+	}
 
 	// 2. [新增] 打印最终生效的配置以供调试
 	// 使用 json 包将配置结构体格式化为可读的字符串
@@ -76,12 +95,47 @@ func main() {
 	r := gin.New()
 
 	jwtUtility := gatewayCore.NewJWTUtility(&cfg, logger)
-	router.SetupRouter(r, &cfg, logger, jwtUtility, otelTransport)
+	routerState := router.SetupRouter(r, &cfg, logger, jwtUtility, otelTransport)
 
 	srv := &http.Server{
 		Addr:    cfg.Server.ListenAddr,
 		Handler: r,
 	}
+	if cfg.Limits.MaxHeaderBytes > 0 {
+		// 兜底限制：明显超限的请求头由标准库直接拒绝，其余由 HeaderLimitMiddleware 返回统一错误
+		srv.MaxHeaderBytes = cfg.Limits.MaxHeaderBytes
+	}
+
+	// --- 可选的 HTTPS 监听 ---
+	var tlsSrv *http.Server
+	stopReload := make(chan struct{})
+	if cfg.TLS != nil && cfg.TLS.Enabled {
+		certStore, err := listener.NewCertStore(cfg.TLS.Certificates, logger)
+		if err != nil {
+			logger.Fatal("加载 TLS 证书失败", zap.Error(err))
+		}
+		tlsConfig, err := listener.NewTLSConfig(cfg.TLS, certStore)
+		if err != nil {
+			logger.Fatal("构建 TLS 配置失败", zap.Error(err))
+		}
+		go certStore.WatchReload(cfg.TLS.ReloadInterval, stopReload)
+
+		tlsSrv = &http.Server{
+			Addr:           cfg.TLS.ListenAddr,
+			Handler:        r,
+			TLSConfig:      tlsConfig,
+			MaxHeaderBytes: srv.MaxHeaderBytes,
+		}
+		if cfg.TLS.RedirectHTTP {
+			srv.Handler = listener.RedirectHandler(cfg.TLS.ListenAddr, r)
+		}
+		go func() {
+			logger.Info("Starting gateway HTTPS server", zap.String("addr", cfg.TLS.ListenAddr))
+			if err := tlsSrv.ListenAndServeTLS("", ""); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				logger.Fatal("Failed to start HTTPS server", zap.Error(err))
+			}
+		}()
+	}
 
 	go func() {
 		if srv.Addr == "" {
@@ -101,8 +155,18 @@ func main() {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
+	close(stopReload)
 	if err := srv.Shutdown(ctx); err != nil {
 		logger.Error("Server shutdown failed", zap.Error(err))
 	}
+	if tlsSrv != nil {
+		if err := tlsSrv.Shutdown(ctx); err != nil {
+			logger.Error("HTTPS server shutdown failed", zap.Error(err))
+		}
+	}
+	// 请求处理完毕后再关闭审计日志，确保最后的记录写入文件
+	if err := routerState.Close(); err != nil {
+		logger.Error("关闭运行时资源失败", zap.Error(err))
+	}
 	logger.Info("Gateway server exited")
 }